@@ -2,17 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/nekitmilk/agent/internal/collector"
+	"github.com/nekitmilk/agent/internal/collector/docker"
+	"github.com/nekitmilk/agent/internal/collector/network"
+	"github.com/nekitmilk/agent/internal/collector/port"
+	"github.com/nekitmilk/agent/internal/collector/process"
 	"github.com/nekitmilk/agent/internal/collector/system"
 	"github.com/nekitmilk/agent/internal/config"
+	"github.com/nekitmilk/agent/internal/enroll"
+	"github.com/nekitmilk/agent/internal/hostfs"
 	"github.com/nekitmilk/agent/internal/models"
+	"github.com/nekitmilk/agent/internal/role"
+	"github.com/nekitmilk/agent/internal/scheduler"
 	"github.com/nekitmilk/agent/internal/sender"
+	"github.com/nekitmilk/agent/internal/spool"
 )
 
 func main() {
@@ -40,73 +53,351 @@ func run(ctx context.Context) error {
 	}
 
 	// Валидация конфигурации
-	if cfg.HostID == "" {
-		return fmt.Errorf("HOST_ID environment variable is required")
-	}
 	if cfg.PollingInterval < 10*time.Second {
 		log.Printf("Warning: Polling interval too low, setting to 10s")
 		cfg.PollingInterval = 10 * time.Second
 	}
 
-	log.Printf("Starting agent for host: %s", cfg.HostID)
+	// Все коллекторы читают /proc, /sys и таблицу монтирования хоста из HOST_ROOT
+	hostRoot := hostfs.New(cfg.HostRoot)
+	tlsConfig, err := sender.TLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSServerName)
+	if err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	metricSender := sender.NewHTTPSender(cfg.MonitoringCenterURL, cfg.RequestTimeout, tlsConfig)
+
 	log.Printf("Monitoring center URL: %s", cfg.MonitoringCenterURL)
+	identity, err := resolveIdentity(ctx, cfg, metricSender, hostRoot)
+	if err != nil {
+		return err
+	}
+	cfg.HostID = identity.HostID
+	if identity.Secret == "" {
+		log.Printf("Warning: no agent key configured, the monitoring center may reject metrics")
+	}
+	metricSender.SetAgentKey(identity.Secret)
+
+	log.Printf("Starting agent for host: %s", cfg.HostID)
 	log.Printf("Polling interval: %v", cfg.PollingInterval)
+	log.Printf("Host root: %s", cfg.HostRoot)
+	log.Printf("Watched processes: %v", cfg.WatchProcesses)
+	log.Printf("Watched containers: %v", cfg.WatchContainers)
+	log.Printf("Role group: %q, state file: %s", cfg.RoleGroup, cfg.RoleStateFile)
 
-	systemCollector := system.NewSystemCollector()
-	metricSender := sender.NewHTTPSender(cfg.MonitoringCenterURL, cfg.RequestTimeout)
+	// Без очереди агент работает как раньше: неотправленный пакет теряется
+	var batchSpool *spool.Spool
+	if cfg.SpoolDir != "" {
+		batchSpool, err = spool.Open(cfg.SpoolDir, cfg.SpoolMaxBytes, cfg.SpoolMaxAge)
+		if err != nil {
+			log.Printf("Warning: spool disabled: %v", err)
+		} else {
+			log.Printf("Spool: %s (%d batches pending)", cfg.SpoolDir, batchSpool.Len())
+		}
+	}
 
-	ticker := time.NewTicker(cfg.PollingInterval)
-	defer ticker.Stop()
+	collectors := &collectors{
+		process: process.NewProcessCollector(cfg.WatchProcesses),
+		port:    port.NewPortCollector(hostRoot),
+		docker: docker.NewDockerCollector(
+			docker.NewSocketClient(cfg.DockerSocket, cfg.RequestTimeout),
+			cfg.WatchContainers,
+		),
+	}
+
+	networkCollector, err := network.NewNetworkCollector(hostRoot, cfg.NetworkExclude)
+	if err != nil {
+		return fmt.Errorf("invalid NETWORK_EXCLUDE: %w", err)
+	}
+
+	fsTypes, err := system.NewFilter(cfg.DiskFSTypes, cfg.DiskExcludeFSTypes)
+	if err != nil {
+		return fmt.Errorf("invalid disk filesystem filter: %w", err)
+	}
+	mounts, err := system.NewFilter(cfg.DiskMounts, cfg.DiskExcludeMounts)
+	if err != nil {
+		return fmt.Errorf("invalid disk mount filter: %w", err)
+	}
+	devices, err := system.NewFilter(nil, cfg.DiskIOExcludeDevices)
+	if err != nil {
+		return fmt.Errorf("invalid disk device filter: %w", err)
+	}
+
+	registry := collector.NewRegistry()
+	registry.Register(system.NewCPUCollector(), schedule(cfg.CPU, cfg.PollingInterval))
+	registry.Register(system.NewRAMCollector(), schedule(cfg.RAM, cfg.PollingInterval))
+	registry.Register(system.NewDiskCollector(hostRoot, fsTypes, mounts), schedule(cfg.Disk, cfg.PollingInterval))
+	registry.Register(system.NewDiskIOCollector(devices), schedule(cfg.DiskIO, cfg.PollingInterval))
+	registry.Register(system.NewPressureCollector(hostRoot), schedule(cfg.Pressure, cfg.PollingInterval))
+	registry.Register(collectors.process, schedule(cfg.Process, cfg.PollingInterval))
+	registry.Register(collectors.port, schedule(cfg.Port, cfg.PollingInterval))
+	registry.Register(collectors.docker, schedule(cfg.Container, cfg.PollingInterval))
+	registry.Register(networkCollector, schedule(cfg.Network, cfg.PollingInterval))
+	for _, info := range registry.Enabled() {
+		log.Printf("Collector %s: interval %v, timeout %v", info.Name, info.Schedule.Interval, info.Schedule.Timeout)
+	}
+
+	go watchConfig(ctx, collectors, metricSender, cfg.HostID, cfg.ConfigPollInterval)
+
+	roleWatcher := role.NewWatcher(metricSender, cfg.HostID, cfg.RoleGroup, cfg.RolePollInterval, cfg.RoleStateFile, role.Hooks{
+		Promote: cfg.PromoteScript,
+		Demote:  cfg.DemoteScript,
+		Timeout: cfg.RoleScriptTimeout,
+	})
+	go roleWatcher.Run(ctx)
+	if cfg.RoleListenAddr != "" {
+		go role.Serve(ctx, cfg.RoleListenAddr, roleWatcher)
+	}
+
+	// Каждый коллектор работает по своему интервалу, накопленные метрики
+	// отправляются раз в POLLING_INTERVAL. Циклы одной задачи не пересекаются.
+	tasks := scheduler.New(cfg.ScheduleJitter)
+	for _, info := range registry.Enabled() {
+		name := info.Name
+		tasks.Add("collector "+name, info.Schedule.Interval, func(ctx context.Context) {
+			registry.Collect(hostRoot.Context(ctx), name)
+		})
+	}
+
+	shipper := &shipper{
+		hostID:    cfg.HostID,
+		registry:  registry,
+		sender:    metricSender,
+		spool:     batchSpool,
+		scheduler: tasks,
+	}
+	tasks.Add("send", cfg.PollingInterval, shipper.safeSend)
+
+	tasks.Run(ctx)
+	log.Println("Shutting down agent gracefully...")
+	return nil
+}
+
+// resolveIdentity определяет ID хоста и ключ агента: из HOST_ID и AGENT_KEY,
+// из сохраненной регистрации или регистрируя хост в ЦМ по ENROLL_TOKEN
+func resolveIdentity(ctx context.Context, cfg *config.Config, client *sender.HTTPSender, hostRoot hostfs.Root) (*models.Identity, error) {
+	if cfg.HostID != "" {
+		return &models.Identity{HostID: cfg.HostID, Secret: cfg.AgentKey}, nil
+	}
+
+	// Испорченный файл не перезаписывается: повторная регистрация создала бы второй хост
+	identity, err := enroll.LoadIdentity(cfg.IdentityFile)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		log.Printf("Using host identity from %s", cfg.IdentityFile)
+		return identity, nil
+	}
+
+	if cfg.EnrollToken == "" {
+		return nil, fmt.Errorf("HOST_ID or ENROLL_TOKEN environment variable is required")
+	}
+
+	hostname, err := enroll.Hostname(hostRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hostname: %w", err)
+	}
+	ips := enroll.IPs(cfg.HostIP, cfg.MonitoringCenterURL)
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("failed to detect host IP, set HOST_IP")
+	}
+
+	request := models.EnrollRequest{
+		Token:    cfg.EnrollToken,
+		Hostname: hostname,
+		IPs:      ips,
+		Hardware: enroll.Inventory(hostRoot.Context(ctx)),
+		Priority: cfg.EnrollPriority,
+		Labels:   cfg.EnrollLabels,
+	}
+	log.Printf("Enrolling host %s (%s) in monitoring center", hostname, ips[0])
+
+	// ЦМ может стартовать позже агента, поэтому недоступность ЦМ не ошибка.
+	// Отказ ЦМ (неверный токен, хост уже существует) повторять бессмысленно.
+	backoff := time.Second
+	for {
+		enrolled, err := client.Enroll(request)
+		if err == nil {
+			identity := &models.Identity{
+				HostID:       enrolled.HostID,
+				CredentialID: enrolled.CredentialID,
+				Secret:       enrolled.Secret,
+				EnrolledAt:   time.Now(),
+			}
+			if err := enroll.SaveIdentity(cfg.IdentityFile, identity); err != nil {
+				return nil, fmt.Errorf("enrolled as host %s but %w", enrolled.HostID, err)
+			}
+			log.Printf("Enrolled as host %s, identity saved to %s", enrolled.HostID, cfg.IdentityFile)
+			return identity, nil
+		}
+
+		var statusErr *sender.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 &&
+			statusErr.StatusCode != http.StatusTooManyRequests {
+			return nil, fmt.Errorf("enrollment refused: %w", err)
+		}
 
-	// Первый сбор
-	safeCollectAndSend(systemCollector, metricSender, cfg.HostID)
+		log.Printf("Enrollment failed: %v, retrying in %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// collectors коллекторы, списки наблюдения которых задаются конфигурацией хоста
+type collectors struct {
+	process *process.ProcessCollector
+	port    *port.PortCollector
+	docker  *docker.DockerCollector
+}
+
+// applyConfig перенастраивает коллекторы по конфигурации хоста из ЦМ
+func (c *collectors) applyConfig(config *models.HostConfig) {
+	c.process.SetWatched(config.Processes)
+	c.docker.SetWatched(config.Containers)
+	c.port.SetWatched(config.Ports)
+}
+
+// watchConfig периодически опрашивает ЦМ и применяет новую конфигурацию
+// хоста без перезапуска агента
+func watchConfig(ctx context.Context, collectors *collectors, sender *sender.HTTPSender, hostID string, interval time.Duration) {
+	var etag string
+	var version int64
+
+	poll := func() {
+		config, newETag, err := sender.FetchHostConfig(hostID, etag)
+		if err != nil {
+			log.Printf("Failed to fetch host config: %v", err)
+			return
+		}
+		etag = newETag
+
+		// Пока конфигурация в ЦМ не задана, работаем со списками из окружения
+		if config == nil || config.Version == 0 || config.Version == version {
+			return
+		}
+
+		version = config.Version
+		collectors.applyConfig(config)
+		log.Printf("Applied host config version %d: processes=%v containers=%v ports=%v",
+			config.Version, config.Processes, config.Containers, config.Ports)
+	}
+
+	poll()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	// Основной цикл
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Shutting down agent gracefully...")
-			return nil
+			return
 		case <-ticker.C:
-			go safeCollectAndSend(systemCollector, metricSender, cfg.HostID)
+			poll()
 		}
 	}
 }
 
-func safeCollectAndSend(collector *system.SystemCollector, sender *sender.HTTPSender, hostID string) {
+// schedule строит расписание коллектора, подставляя интервал по умолчанию
+func schedule(cfg config.CollectorConfig, defaultInterval time.Duration) collector.Schedule {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return collector.Schedule{
+		Enabled:  cfg.Enabled,
+		Interval: interval,
+		Timeout:  cfg.Timeout,
+	}
+}
+
+// shipper отправляет накопленные метрики в ЦМ, ставя неотправленные пакеты в очередь
+type shipper struct {
+	hostID    string
+	registry  *collector.Registry
+	sender    *sender.HTTPSender
+	spool     *spool.Spool // nil - очередь отключена
+	scheduler *scheduler.Scheduler
+
+	// Пакеты, отклоненные ЦМ при первой отправке. Отклоненные при досылке
+	// из очереди учитывает сама очередь.
+	rejected atomic.Uint64
+}
+
+func (s *shipper) safeSend(ctx context.Context) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Recovered from panic: %v", r)
 		}
 	}()
 
-	if err := collectAndSend(collector, sender, hostID); err != nil {
-		log.Printf("Collection failed: %v", err)
+	if err := s.send(ctx); err != nil {
+		log.Printf("Sending failed: %v", err)
 	}
 }
 
-func collectAndSend(collector *system.SystemCollector, sender *sender.HTTPSender, hostID string) error {
-	metrics, err := collector.Collect()
-	if err != nil {
-		return fmt.Errorf("failed to collect metrics: %w", err)
+func (s *shipper) send(ctx context.Context) error {
+	metrics := s.registry.Drain()
+	if len(metrics) == 0 {
+		return nil
 	}
 
+	now := time.Now()
+	metrics = append(metrics, s.agentMetric(now))
+
 	batch := models.MetricsRequest{
-		HostID:    hostID,
+		HostID:    s.hostID,
 		Metrics:   metrics,
-		Timestamp: time.Now(),
+		Timestamp: now,
+	}
+
+	// Сначала досылаем накопленные пакеты, чтобы ЦМ получил их по порядку.
+	// Если ЦМ все еще недоступен, новый пакет сразу встает в очередь.
+	if s.spool != nil && s.spool.Len() > 0 {
+		sent, err := s.spool.Replay(s.sendBatch)
+		if sent > 0 {
+			log.Printf("Replayed %d spooled batches", sent)
+		}
+		if err != nil {
+			return s.spoolBatch(batch, fmt.Errorf("failed to replay spool: %w", err))
+		}
 	}
 
 	maxRetries := 3
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if err := sender.SendMetrics(batch); err != nil {
+		if err := s.sendBatch(batch); err != nil {
+			if errors.Is(err, spool.ErrRejected) {
+				s.rejected.Add(1)
+				return err
+			}
 			if attempt == maxRetries {
-				return fmt.Errorf("failed after %d attempts: %w", maxRetries, err)
+				return s.spoolBatch(batch, fmt.Errorf("failed after %d attempts: %w", maxRetries, err))
 			}
 
 			backoff := time.Duration(attempt*attempt) * time.Second
+
+			// ЦМ перегружен и сам сказал, когда повторить: с очередью пакет
+			// ждет в ней следующего цикла, без очереди ждем столько, сколько просит ЦМ
+			var statusErr *sender.StatusError
+			if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+				if s.spool != nil {
+					return s.spoolBatch(batch, fmt.Errorf("center is overloaded: %w", err))
+				}
+				backoff = statusErr.RetryAfter
+			}
+
 			log.Printf("Attempt %d failed: %v, retrying in %v", attempt, err, backoff)
-			time.Sleep(backoff)
+			select {
+			case <-ctx.Done():
+				return s.spoolBatch(batch, fmt.Errorf("agent is shutting down: %w", err))
+			case <-time.After(backoff):
+			}
 			continue
 		}
 
@@ -116,3 +407,51 @@ func collectAndSend(collector *system.SystemCollector, sender *sender.HTTPSender
 
 	return nil
 }
+
+// sendBatch отправляет пакет, помечая отклоненные ЦМ пакеты как spool.ErrRejected
+func (s *shipper) sendBatch(batch models.MetricsRequest) error {
+	err := s.sender.SendMetrics(batch)
+
+	var statusErr *sender.StatusError
+	if errors.As(err, &statusErr) && statusErr.Rejected() {
+		return fmt.Errorf("%w: %v", spool.ErrRejected, err)
+	}
+
+	return err
+}
+
+// spoolBatch ставит неотправленный пакет в очередь и дополняет ошибку отправки
+func (s *shipper) spoolBatch(batch models.MetricsRequest, sendErr error) error {
+	if s.spool == nil {
+		return sendErr
+	}
+
+	if err := s.spool.Push(batch); err != nil {
+		return fmt.Errorf("%w; failed to spool batch: %v", sendErr, err)
+	}
+
+	return fmt.Errorf("%w; batch spooled (%d pending)", sendErr, s.spool.Len())
+}
+
+// agentMetric сообщает ЦМ о состоянии очереди неотправленных пакетов
+// и о пропущенных и затянувшихся циклах планировщика
+func (s *shipper) agentMetric(timestamp time.Time) models.Metric {
+	data := models.AgentData{DroppedBatches: s.rejected.Load()}
+	if s.spool != nil {
+		stats := s.spool.Stats()
+		data.SpoolBatches = stats.Batches
+		data.SpoolBytes = stats.Bytes
+		data.DroppedBatches += stats.Dropped
+	}
+	for _, stats := range s.scheduler.Stats() {
+		data.SkippedCycles += stats.Skipped
+		data.OverrunCycles += stats.Overruns
+	}
+
+	return models.Metric{
+		Type:      models.MetricAgent,
+		Value:     float64(data.DroppedBatches),
+		Data:      data,
+		Timestamp: timestamp,
+	}
+}
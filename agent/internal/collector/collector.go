@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// Collector источник метрик агента. Collect должен уважать отмену ctx:
+// по ней истекает таймаут сбора.
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context) ([]models.Metric, error)
+}
+
+// Schedule расписание коллектора
+type Schedule struct {
+	Enabled  bool
+	Interval time.Duration
+	Timeout  time.Duration // 0 - без ограничения
+}
+
+type entry struct {
+	collector Collector
+	schedule  Schedule
+}
+
+// Registry хранит коллекторы с их расписаниями и копит собранные метрики
+// до отправки. Ошибка одного коллектора не влияет на остальные.
+type Registry struct {
+	entries []entry
+
+	mu      sync.Mutex
+	pending []models.Metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register добавляет коллектор. Выключенные коллекторы не запускаются.
+func (r *Registry) Register(c Collector, schedule Schedule) {
+	r.entries = append(r.entries, entry{collector: c, schedule: schedule})
+}
+
+// Info имя и расписание коллектора
+type Info struct {
+	Name     string
+	Schedule Schedule
+}
+
+// Enabled возвращает включенные коллекторы в порядке регистрации
+func (r *Registry) Enabled() []Info {
+	var enabled []Info
+	for _, e := range r.entries {
+		if e.schedule.Enabled {
+			enabled = append(enabled, Info{Name: e.collector.Name(), Schedule: e.schedule})
+		}
+	}
+	return enabled
+}
+
+// Drain забирает накопленные метрики
+func (r *Registry) Drain() []models.Metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := r.pending
+	r.pending = nil
+	return metrics
+}
+
+// Collect выполняет один сбор коллектора name
+func (r *Registry) Collect(ctx context.Context, name string) {
+	for _, e := range r.entries {
+		if e.collector.Name() == name {
+			r.collect(ctx, e)
+			return
+		}
+	}
+}
+
+// collect выполняет один сбор с таймаутом. Метрики без времени получают
+// время сбора, чтобы не зависеть от момента отправки.
+func (r *Registry) collect(ctx context.Context, e entry) {
+	name := e.collector.Name()
+
+	if e.schedule.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.schedule.Timeout)
+		defer cancel()
+	}
+
+	metrics, err := safeCollect(ctx, e.collector)
+	if err != nil {
+		// Результат коллектора, прерванного по таймауту, отбрасывается.
+		// Коллектор, успевший закончить к сроку, ошибки не вернет.
+		if ctx.Err() == context.DeadlineExceeded {
+			log.Printf("Collector %s timed out after %v", name, e.schedule.Timeout)
+			return
+		}
+		// Частичный результат все равно отправляется
+		log.Printf("Collector %s failed: %v", name, err)
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for i := range metrics {
+		if metrics[i].Timestamp.IsZero() {
+			metrics[i].Timestamp = now
+		}
+	}
+
+	r.mu.Lock()
+	r.pending = append(r.pending, metrics...)
+	r.mu.Unlock()
+}
+
+func safeCollect(ctx context.Context, c Collector) (metrics []models.Metric, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return c.Collect(ctx)
+}
@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// fakeCollector отдает результат collect
+type fakeCollector struct {
+	collect func(ctx context.Context) ([]models.Metric, error)
+}
+
+func (c *fakeCollector) Name() string { return "fake" }
+
+func (c *fakeCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	return c.collect(ctx)
+}
+
+func cpu(value float64) models.Metric {
+	return models.Metric{Type: models.MetricCPU, Value: value}
+}
+
+func TestRegistryCollect(t *testing.T) {
+	stamped := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		collect func(ctx context.Context) ([]models.Metric, error)
+		want    []float64
+	}{
+		{
+			name:    "in time",
+			timeout: time.Second,
+			collect: func(ctx context.Context) ([]models.Metric, error) {
+				return []models.Metric{cpu(1), cpu(2)}, nil
+			},
+			want: []float64{1, 2},
+		},
+		{
+			// Сбор закончился ровно к сроку: контекст уже истек, но ошибки нет
+			name:    "returns at the deadline",
+			timeout: 20 * time.Millisecond,
+			collect: func(ctx context.Context) ([]models.Metric, error) {
+				<-ctx.Done()
+				return []models.Metric{cpu(1)}, nil
+			},
+			want: []float64{1},
+		},
+		{
+			// Прерванный сбор отбрасывается вместе с частичным результатом
+			name:    "overruns the deadline",
+			timeout: 20 * time.Millisecond,
+			collect: func(ctx context.Context) ([]models.Metric, error) {
+				<-ctx.Done()
+				return []models.Metric{cpu(1)}, ctx.Err()
+			},
+		},
+		{
+			name: "failed with partial result",
+			collect: func(ctx context.Context) ([]models.Metric, error) {
+				return []models.Metric{cpu(3)}, errors.New("permission denied")
+			},
+			want: []float64{3},
+		},
+		{
+			name: "panic",
+			collect: func(ctx context.Context) ([]models.Metric, error) {
+				panic("boom")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Register(&fakeCollector{collect: tt.collect}, Schedule{Enabled: true, Timeout: tt.timeout})
+
+			registry.Collect(context.Background(), "fake")
+
+			metrics := registry.Drain()
+			if len(metrics) != len(tt.want) {
+				t.Fatalf("collected %d metrics, want %d", len(metrics), len(tt.want))
+			}
+			for i, metric := range metrics {
+				if metric.Value != tt.want[i] {
+					t.Errorf("metric %d value %v, want %v", i, metric.Value, tt.want[i])
+				}
+				if metric.Timestamp.IsZero() {
+					t.Errorf("metric %d has no timestamp", i)
+				}
+			}
+			if registry.Drain() != nil {
+				t.Error("Drain must empty the registry")
+			}
+		})
+	}
+
+	// Время, выставленное коллектором, не перезаписывается
+	registry := NewRegistry()
+	registry.Register(&fakeCollector{collect: func(ctx context.Context) ([]models.Metric, error) {
+		return []models.Metric{{Type: models.MetricCPU, Timestamp: stamped}}, nil
+	}}, Schedule{Enabled: true})
+	registry.Collect(context.Background(), "fake")
+	if metrics := registry.Drain(); len(metrics) != 1 || !metrics[0].Timestamp.Equal(stamped) {
+		t.Errorf("metrics %v, want collector timestamp %v kept", metrics, stamped)
+	}
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Container описывает контейнер в ответе Docker Engine API /containers/json
+type Container struct {
+	ID     string   `json:"Id"`
+	Names  []string `json:"Names"`
+	Image  string   `json:"Image"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+// Client - минимальный интерфейс к Docker Engine API, нужный коллектору
+type Client interface {
+	ListContainers(ctx context.Context) ([]Container, error)
+}
+
+// SocketClient обращается к Docker Engine API через unix-сокет
+type SocketClient struct {
+	client *http.Client
+}
+
+func NewSocketClient(socketPath string, timeout time.Duration) *SocketClient {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	return &SocketClient{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+	}
+}
+
+// ListContainers возвращает все контейнеры, включая остановленные
+func (c *SocketClient) ListContainers(ctx context.Context) ([]Container, error) {
+	// Хост в URL игнорируется: соединение всегда идет через сокет
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/containers/json?all=1", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query docker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var containers []Container
+	if err := json.NewDecoder(resp.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("failed to decode containers: %w", err)
+	}
+
+	return containers, nil
+}
@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// Состояние, которым помечается контейнер из конфигурации, не найденный в Docker
+const StateMissing = "missing"
+
+// DockerCollector собирает состояние контейнеров из конфигурации хоста
+type DockerCollector struct {
+	client Client
+
+	mu      sync.Mutex
+	watched []string
+}
+
+func NewDockerCollector(client Client, watched []string) *DockerCollector {
+	c := &DockerCollector{client: client}
+	c.SetWatched(watched)
+	return c
+}
+
+func (c *DockerCollector) Name() string {
+	return string(models.MetricContainer)
+}
+
+// SetWatched заменяет список отслеживаемых контейнеров (имена или ID)
+func (c *DockerCollector) SetWatched(names []string) {
+	var watched []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		watched = append(watched, name)
+	}
+
+	c.mu.Lock()
+	c.watched = watched
+	c.mu.Unlock()
+}
+
+// Collect возвращает по одной метрике на каждый контейнер из конфигурации.
+// Отсутствующий контейнер попадает в результат с флагом Missing.
+func (c *DockerCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	c.mu.Lock()
+	watched := c.watched
+	c.mu.Unlock()
+
+	if len(watched) == 0 {
+		return nil, nil
+	}
+
+	containers, err := c.client.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]models.Metric, 0, len(watched))
+	for _, name := range watched {
+		data := models.ContainerData{
+			Name:    name,
+			Status:  "not found",
+			State:   StateMissing,
+			Missing: true,
+		}
+
+		if container, ok := findContainer(containers, name); ok {
+			data = models.ContainerData{
+				ID:     container.ID,
+				Name:   name,
+				Image:  container.Image,
+				Status: container.Status,
+				State:  container.State,
+			}
+		}
+
+		value := 0.0
+		if data.State == "running" {
+			value = 1
+		}
+
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricContainer,
+			Value: value,
+			Data:  data,
+		})
+	}
+
+	return metrics, nil
+}
+
+// findContainer ищет контейнер по имени, а если не нашел - по префиксу ID
+func findContainer(containers []Container, name string) (Container, bool) {
+	for _, container := range containers {
+		for _, containerName := range container.Names {
+			if strings.TrimPrefix(containerName, "/") == name {
+				return container, true
+			}
+		}
+	}
+
+	if len(name) >= 12 {
+		for _, container := range containers {
+			if strings.HasPrefix(container.ID, name) {
+				return container, true
+			}
+		}
+	}
+
+	return Container{}, false
+}
@@ -0,0 +1,184 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+const containersJSON = `[
+	{"Id": "4f1c2a9be3d07788aa", "Names": ["/web"], "Image": "nginx:1.27", "State": "running", "Status": "Up 2 hours"},
+	{"Id": "9a8b7c6d5e4f3322bb", "Names": ["/worker", "/web/worker"], "Image": "app:latest", "State": "exited", "Status": "Exited (1) 5 minutes ago"},
+	{"Id": "0123456789abcdef00", "Names": ["/db"], "Image": "postgres:16", "State": "restarting", "Status": "Restarting (1) 3 seconds ago"}
+]`
+
+// newSocketServer поднимает HTTP-сервер на unix-сокете, как у Docker Engine
+func newSocketServer(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	// Путь к сокету ограничен ~108 байтами, t.TempDir может оказаться длиннее
+	dir, err := os.MkdirTemp("", "docker")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", socketPath, err)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return socketPath
+}
+
+func containersHandler(t *testing.T, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/json" || r.URL.Query().Get("all") != "1" {
+			t.Errorf("unexpected request: %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+}
+
+func TestSocketClientListContainers(t *testing.T) {
+	socketPath := newSocketServer(t, containersHandler(t, containersJSON))
+	client := NewSocketClient(socketPath, time.Second)
+
+	containers, err := client.ListContainers(context.Background())
+	if err != nil {
+		t.Fatalf("ListContainers: %v", err)
+	}
+	if len(containers) != 3 {
+		t.Fatalf("got %d containers, want 3", len(containers))
+	}
+
+	web := containers[0]
+	if web.ID != "4f1c2a9be3d07788aa" || web.Names[0] != "/web" || web.Image != "nginx:1.27" ||
+		web.State != "running" || web.Status != "Up 2 hours" {
+		t.Errorf("unexpected container: %+v", web)
+	}
+}
+
+func TestSocketClientErrorStatus(t *testing.T) {
+	socketPath := newSocketServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	client := NewSocketClient(socketPath, time.Second)
+
+	if _, err := client.ListContainers(context.Background()); err == nil {
+		t.Fatal("expected error on 500 response")
+	}
+}
+
+func TestDockerCollectorCollect(t *testing.T) {
+	socketPath := newSocketServer(t, containersHandler(t, containersJSON))
+	client := NewSocketClient(socketPath, time.Second)
+
+	tests := []struct {
+		name    string
+		watched []string
+		want    []models.ContainerData
+		values  []float64
+	}{
+		{
+			name:    "name without leading slash",
+			watched: []string{"web"},
+			want: []models.ContainerData{
+				{ID: "4f1c2a9be3d07788aa", Name: "web", Image: "nginx:1.27", State: "running", Status: "Up 2 hours"},
+			},
+			values: []float64{1},
+		},
+		{
+			name:    "name with leading slash and spaces",
+			watched: []string{" /worker "},
+			want: []models.ContainerData{
+				{ID: "9a8b7c6d5e4f3322bb", Name: "worker", Image: "app:latest", State: "exited", Status: "Exited (1) 5 minutes ago"},
+			},
+			values: []float64{0},
+		},
+		{
+			name:    "ID prefix",
+			watched: []string{"0123456789ab"},
+			want: []models.ContainerData{
+				{ID: "0123456789abcdef00", Name: "0123456789ab", Image: "postgres:16", State: "restarting", Status: "Restarting (1) 3 seconds ago"},
+			},
+			values: []float64{0},
+		},
+		{
+			name:    "short ID prefix is not matched",
+			watched: []string{"0123"},
+			want: []models.ContainerData{
+				{Name: "0123", State: StateMissing, Status: "not found", Missing: true},
+			},
+			values: []float64{0},
+		},
+		{
+			name:    "missing configured container",
+			watched: []string{"web", "cache", "/web"},
+			want: []models.ContainerData{
+				{ID: "4f1c2a9be3d07788aa", Name: "web", Image: "nginx:1.27", State: "running", Status: "Up 2 hours"},
+				{Name: "cache", State: StateMissing, Status: "not found", Missing: true},
+			},
+			values: []float64{1, 0},
+		},
+		{
+			name:    "nothing watched",
+			watched: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewDockerCollector(client, tt.watched)
+
+			metrics, err := collector.Collect(context.Background())
+			if err != nil {
+				t.Fatalf("Collect: %v", err)
+			}
+			if len(metrics) != len(tt.want) {
+				t.Fatalf("got %d metrics, want %d", len(metrics), len(tt.want))
+			}
+
+			for i, metric := range metrics {
+				if metric.Type != models.MetricContainer {
+					t.Errorf("metric %d: type %q, want %q", i, metric.Type, models.MetricContainer)
+				}
+				data, ok := metric.Data.(models.ContainerData)
+				if !ok {
+					t.Fatalf("metric %d: data is %T, want models.ContainerData", i, metric.Data)
+				}
+				if data != tt.want[i] {
+					t.Errorf("metric %d: got %+v, want %+v", i, data, tt.want[i])
+				}
+				if metric.Value != tt.values[i] {
+					t.Errorf("metric %d: value %v, want %v", i, metric.Value, tt.values[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDockerCollectorSocketUnavailable(t *testing.T) {
+	client := NewSocketClient(filepath.Join(t.TempDir(), "missing.sock"), time.Second)
+	collector := NewDockerCollector(client, []string{"web"})
+
+	if _, err := collector.Collect(context.Background()); err == nil {
+		t.Fatal("expected error when the docker socket is unavailable")
+	}
+}
@@ -0,0 +1,143 @@
+package network
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/hostfs"
+	"github.com/nekitmilk/agent/internal/models"
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// NetworkCollector собирает пропускную способность и ошибки сетевых
+// интерфейсов. Счетчики ядра накопительные, поэтому метрики считаются
+// как скорость между двумя замерами: первый сбор только запоминает счетчики.
+type NetworkCollector struct {
+	root    hostfs.Root
+	exclude []*regexp.Regexp
+
+	mu       sync.Mutex
+	previous map[string]sample
+}
+
+type sample struct {
+	counters psnet.IOCountersStat
+	at       time.Time
+}
+
+// NewNetworkCollector создает коллектор. Интерфейсы, имя которых подходит
+// под одно из выражений exclude, не учитываются.
+func NewNetworkCollector(root hostfs.Root, exclude []string) (*NetworkCollector, error) {
+	c := &NetworkCollector{root: root, previous: make(map[string]sample)}
+	for _, pattern := range exclude {
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		c.exclude = append(c.exclude, re)
+	}
+	return c, nil
+}
+
+func (c *NetworkCollector) Name() string {
+	return string(models.MetricNetwork)
+}
+
+func (c *NetworkCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	counters, err := c.counters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Name < counters[j].Name })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := make(map[string]sample, len(counters))
+	var metrics []models.Metric
+	for _, counter := range counters {
+		if c.excluded(counter.Name) {
+			continue
+		}
+		current[counter.Name] = sample{counters: counter, at: now}
+
+		prev, ok := c.previous[counter.Name]
+		if !ok {
+			continue
+		}
+		data, ok := rates(prev, sample{counters: counter, at: now})
+		if !ok {
+			continue
+		}
+
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricNetwork,
+			Value: data.RxBytesPerSec + data.TxBytesPerSec,
+			Data:  data,
+		})
+	}
+	// Пропавшие интерфейсы забываются вместе с их счетчиками
+	c.previous = current
+
+	return metrics, nil
+}
+
+// counters читает счетчики интерфейсов. /proc/net указывает на сетевое
+// пространство имен читающего процесса, поэтому в контейнере счетчики
+// хоста берутся от имени его PID 1.
+func (c *NetworkCollector) counters(ctx context.Context) ([]psnet.IOCountersStat, error) {
+	if c.root.IsHost() {
+		return psnet.IOCountersWithContext(ctx, true)
+	}
+	return psnet.IOCountersByFileWithContext(ctx, true, c.root.Path("proc", "1", "net", "dev"))
+}
+
+func (c *NetworkCollector) excluded(name string) bool {
+	for _, re := range c.exclude {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// rates считает скорости между замерами. Если счетчик уменьшился
+// (интерфейс пересоздан или счетчик переполнился), замер пропускается.
+func rates(prev, cur sample) (models.NetworkData, bool) {
+	seconds := cur.at.Sub(prev.at).Seconds()
+	if seconds <= 0 {
+		return models.NetworkData{}, false
+	}
+
+	p, n := prev.counters, cur.counters
+	if n.BytesRecv < p.BytesRecv || n.BytesSent < p.BytesSent ||
+		n.PacketsRecv < p.PacketsRecv || n.PacketsSent < p.PacketsSent ||
+		n.Errin < p.Errin || n.Errout < p.Errout ||
+		n.Dropin < p.Dropin || n.Dropout < p.Dropout {
+		return models.NetworkData{}, false
+	}
+
+	rate := func(prev, cur uint64) float64 {
+		return float64(cur-prev) / seconds
+	}
+
+	return models.NetworkData{
+		Interface:       n.Name,
+		RxBytesPerSec:   rate(p.BytesRecv, n.BytesRecv),
+		TxBytesPerSec:   rate(p.BytesSent, n.BytesSent),
+		RxPacketsPerSec: rate(p.PacketsRecv, n.PacketsRecv),
+		TxPacketsPerSec: rate(p.PacketsSent, n.PacketsSent),
+		RxErrorsPerSec:  rate(p.Errin, n.Errin),
+		TxErrorsPerSec:  rate(p.Errout, n.Errout),
+		RxDropsPerSec:   rate(p.Dropin, n.Dropin),
+		TxDropsPerSec:   rate(p.Dropout, n.Dropout),
+		IntervalSeconds: seconds,
+	}, true
+}
@@ -0,0 +1,79 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+func TestRates(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	prev := sample{
+		counters: psnet.IOCountersStat{
+			Name: "eth0", BytesRecv: 1000, BytesSent: 2000, PacketsRecv: 10, PacketsSent: 20,
+			Errin: 1, Errout: 2, Dropin: 3, Dropout: 4,
+		},
+		at: at,
+	}
+	// after возвращает замер через interval с приростом счетчиков на delta
+	after := func(interval time.Duration, delta uint64) sample {
+		c := prev.counters
+		c.BytesRecv += 100 * delta
+		c.BytesSent += 200 * delta
+		c.PacketsRecv += delta
+		c.PacketsSent += 2 * delta
+		c.Errin += delta
+		c.Dropout += delta
+		return sample{counters: c, at: at.Add(interval)}
+	}
+	// wrapped возвращает замер, где счетчик field переполнился или сброшен
+	wrapped := func(field func(c *psnet.IOCountersStat)) sample {
+		s := after(10*time.Second, 5)
+		field(&s.counters)
+		return s
+	}
+
+	tests := []struct {
+		name string
+		cur  sample
+		ok   bool
+		want models.NetworkData
+	}{
+		{
+			name: "traffic",
+			cur:  after(10*time.Second, 50),
+			ok:   true,
+			want: models.NetworkData{
+				Interface: "eth0", RxBytesPerSec: 500, TxBytesPerSec: 1000, RxPacketsPerSec: 5, TxPacketsPerSec: 10,
+				RxErrorsPerSec: 5, TxDropsPerSec: 5, IntervalSeconds: 10,
+			},
+		},
+		{
+			name: "idle interface",
+			cur:  after(2*time.Second, 0),
+			ok:   true,
+			want: models.NetworkData{Interface: "eth0", IntervalSeconds: 2},
+		},
+		{name: "zero interval", cur: after(0, 5)},
+		{name: "clock went back", cur: after(-time.Second, 5)},
+		{name: "interface recreated", cur: sample{counters: psnet.IOCountersStat{Name: "eth0", BytesRecv: 10}, at: at.Add(10 * time.Second)}},
+		{name: "rx bytes wrapped", cur: wrapped(func(c *psnet.IOCountersStat) { c.BytesRecv = 5 })},
+		{name: "tx packets wrapped", cur: wrapped(func(c *psnet.IOCountersStat) { c.PacketsSent = 0 })},
+		{name: "tx errors reset", cur: wrapped(func(c *psnet.IOCountersStat) { c.Errout = 0 })},
+		{name: "rx drops reset", cur: wrapped(func(c *psnet.IOCountersStat) { c.Dropin = 0 })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rates(prev, tt.cur)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("rates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
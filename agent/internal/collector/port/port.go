@@ -0,0 +1,353 @@
+package port
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nekitmilk/agent/internal/hostfs"
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// Состояния сокетов из include/net/tcp_states.h
+const (
+	tcpListen = "0A"
+	udpClose  = "07" // Несвязанный UDP-сокет, т.е. ожидающий датаграммы
+)
+
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed" // Порт из конфигурации хоста, который никто не слушает
+)
+
+// Таблицы сокетов в /proc/<pid>/net
+var socketTables = []struct {
+	file     string
+	protocol string
+	state    string
+	label    string
+}{
+	{file: "tcp", protocol: "tcp", state: tcpListen, label: "LISTEN"},
+	{file: "tcp6", protocol: "tcp6", state: tcpListen, label: "LISTEN"},
+	{file: "udp", protocol: "udp", state: udpClose, label: "UNCONN"},
+	{file: "udp6", protocol: "udp6", state: udpClose, label: "UNCONN"},
+}
+
+// Запасной справочник на случай, если на хосте нет /etc/services
+var wellKnownServices = map[string]string{
+	"tcp/21":    "ftp",
+	"tcp/22":    "ssh",
+	"tcp/25":    "smtp",
+	"udp/53":    "domain",
+	"tcp/53":    "domain",
+	"udp/67":    "bootps",
+	"udp/68":    "bootpc",
+	"tcp/80":    "http",
+	"udp/123":   "ntp",
+	"tcp/443":   "https",
+	"tcp/2375":  "docker",
+	"tcp/2376":  "docker-s",
+	"tcp/3306":  "mysql",
+	"tcp/5432":  "postgresql",
+	"tcp/6379":  "redis",
+	"tcp/8080":  "http-alt",
+	"tcp/27017": "mongodb",
+}
+
+type socket struct {
+	protocol string
+	address  string
+	port     int
+	state    string
+	inode    string
+}
+
+type owner struct {
+	pid  int
+	name string
+}
+
+// PortCollector собирает слушающие TCP и UDP сокеты хоста
+type PortCollector struct {
+	root hostfs.Root
+
+	mu      sync.Mutex
+	watched []models.WatchedPort
+}
+
+// NewPortCollector создает коллектор, читающий таблицы сокетов хоста из root
+func NewPortCollector(root hostfs.Root) *PortCollector {
+	return &PortCollector{root: root}
+}
+
+// SetWatched задает порты, которые должны быть открыты на хосте.
+// Неслушаемые порты из этого списка попадают в результат со статусом "closed".
+func (c *PortCollector) SetWatched(ports []models.WatchedPort) {
+	c.mu.Lock()
+	c.watched = append([]models.WatchedPort(nil), ports...)
+	c.mu.Unlock()
+}
+
+func (c *PortCollector) Name() string {
+	return string(models.MetricPort)
+}
+
+func (c *PortCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var sockets []socket
+	var lastErr error
+	readTables := 0
+
+	for _, table := range socketTables {
+		entries, err := readSocketTable(filepath.Join(c.netDir(), table.file), table.protocol, table.state, table.label)
+		if err != nil {
+			// tcp6/udp6 отсутствуют, если IPv6 выключен в ядре
+			lastErr = err
+			continue
+		}
+		readTables++
+		sockets = append(sockets, entries...)
+	}
+
+	if readTables == 0 {
+		return nil, fmt.Errorf("failed to read socket tables: %w", lastErr)
+	}
+
+	sort.SliceStable(sockets, func(i, j int) bool {
+		if sockets[i].port != sockets[j].port {
+			return sockets[i].port < sockets[j].port
+		}
+		return sockets[i].protocol < sockets[j].protocol
+	})
+
+	owners := c.socketOwners()
+	services := c.loadServices()
+
+	metrics := make([]models.Metric, 0, len(sockets))
+	for _, s := range sockets {
+		data := models.PortData{
+			Port:     s.port,
+			Protocol: s.protocol,
+			Address:  s.address,
+			Status:   StatusOpen,
+			State:    s.state,
+			Service:  serviceName(services, s.protocol, s.port),
+		}
+		if o, ok := owners[s.inode]; ok {
+			data.PID = o.pid
+			data.Process = o.name
+		}
+
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricPort,
+			Value: 1,
+			Data:  data,
+		})
+	}
+
+	c.mu.Lock()
+	watched := c.watched
+	c.mu.Unlock()
+
+	for _, port := range watched {
+		if isListening(sockets, port) {
+			continue
+		}
+
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricPort,
+			Value: 0,
+			Data: models.PortData{
+				Port:     port.Port,
+				Protocol: port.Protocol,
+				Status:   StatusClosed,
+				Service:  serviceName(services, port.Protocol, port.Port),
+			},
+		})
+	}
+
+	return metrics, nil
+}
+
+// isListening проверяет, слушается ли порт по IPv4 или IPv6
+func isListening(sockets []socket, port models.WatchedPort) bool {
+	for _, s := range sockets {
+		if s.port == port.Port && strings.TrimSuffix(s.protocol, "6") == port.Protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// netDir возвращает каталог с таблицами сокетов. /proc/net указывает на
+// сетевое пространство имен читающего процесса, поэтому при смонтированном
+// корне хоста таблицы читаются от имени PID 1 хоста.
+func (c *PortCollector) netDir() string {
+	if c.root.IsHost() {
+		return "/proc/net"
+	}
+	return c.root.Path("proc", "1", "net")
+}
+
+func readSocketTable(path, protocol, state, label string) ([]socket, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sockets []socket
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // Заголовок таблицы
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+
+		address, port, err := parseHexAddress(fields[1])
+		if err != nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%d", address, port)
+		if seen[key] {
+			continue // SO_REUSEPORT дает несколько сокетов на один адрес
+		}
+		seen[key] = true
+
+		sockets = append(sockets, socket{
+			protocol: protocol,
+			address:  address,
+			port:     port,
+			state:    label,
+			inode:    fields[9],
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sockets, nil
+}
+
+// parseHexAddress разбирает адрес вида "0100007F:1F90". Ядро выводит
+// адрес как последовательность 32-битных слов в порядке байтов хоста.
+func parseHexAddress(value string) (string, int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("malformed address %q", value)
+	}
+
+	raw, err := hex.DecodeString(parts[0])
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("malformed ip %q", parts[0])
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed port %q", parts[1])
+	}
+
+	return net.IP(raw).String(), int(port), nil
+}
+
+// socketOwners сопоставляет inode сокетов с процессами через /proc/<pid>/fd.
+// Без прав на чужие процессы часть сокетов останется без владельца.
+func (c *PortCollector) socketOwners() map[string]owner {
+	owners := make(map[string]owner)
+	procDir := c.root.Path("proc")
+
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return owners
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join(procDir, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		var name string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+			if _, ok := owners[inode]; ok {
+				continue
+			}
+			if name == "" {
+				name = processName(procDir, entry.Name())
+			}
+			owners[inode] = owner{pid: pid, name: name}
+		}
+	}
+
+	return owners
+}
+
+func processName(procDir, pid string) string {
+	comm, err := os.ReadFile(filepath.Join(procDir, pid, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// loadServices читает /etc/services хоста в виде "tcp/22" -> "ssh"
+func (c *PortCollector) loadServices() map[string]string {
+	services := make(map[string]string)
+
+	file, err := os.Open(c.root.Path("etc", "services"))
+	if err != nil {
+		return services
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if _, ok := services[fields[1]]; !ok {
+			services[fields[1]] = fields[0]
+		}
+	}
+
+	return services
+}
+
+func serviceName(services map[string]string, protocol string, port int) string {
+	key := fmt.Sprintf("%s/%d", strings.TrimSuffix(protocol, "6"), port)
+	if name, ok := services[key]; ok {
+		return name
+	}
+	return wellKnownServices[key]
+}
@@ -0,0 +1,195 @@
+package process
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nekitmilk/agent/internal/models"
+	psprocess "github.com/shirou/gopsutil/v3/process"
+)
+
+// Статус, которым помечается отслеживаемый процесс, не найденный на хосте
+const StatusNotRunning = "not running"
+
+// Linux обрезает имя процесса в /proc/<pid>/status до 15 символов
+const maxCommLength = 15
+
+// ProcessCollector собирает состояние процессов из списка наблюдения хоста
+type ProcessCollector struct {
+	mu      sync.Mutex
+	watched []string
+	// Процессы с прошлого сбора: нужны, чтобы считать CPU по разнице между замерами
+	tracked map[int32]*trackedProcess
+}
+
+type trackedProcess struct {
+	proc       *psprocess.Process
+	createTime int64
+	primed     bool // Был ли уже сделан хотя бы один замер CPU
+}
+
+func NewProcessCollector(watched []string) *ProcessCollector {
+	c := &ProcessCollector{
+		tracked: make(map[int32]*trackedProcess),
+	}
+	c.SetWatched(watched)
+	return c
+}
+
+func (c *ProcessCollector) Name() string {
+	return string(models.MetricProcess)
+}
+
+// SetWatched заменяет список отслеживаемых имен процессов
+func (c *ProcessCollector) SetWatched(names []string) {
+	var watched []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		watched = append(watched, name)
+	}
+
+	c.mu.Lock()
+	c.watched = watched
+	c.mu.Unlock()
+}
+
+// Collect возвращает по одной метрике на каждое отслеживаемое имя.
+// Отсутствующий процесс попадает в результат со статусом "not running".
+func (c *ProcessCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.watched) == 0 {
+		return nil, nil
+	}
+
+	procs, err := psprocess.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string][]*trackedProcess)
+	alive := make(map[int32]bool)
+	for _, p := range procs {
+		name, err := p.NameWithContext(ctx)
+		if err != nil {
+			continue // Процесс мог завершиться во время обхода
+		}
+		for _, watched := range c.watched {
+			if matchName(ctx, p, name, watched) {
+				tracked := c.track(ctx, p)
+				alive[p.Pid] = true
+				matches[watched] = append(matches[watched], tracked)
+			}
+		}
+	}
+
+	// Забываем процессы, которые больше не попадают под наблюдение
+	for pid := range c.tracked {
+		if !alive[pid] {
+			delete(c.tracked, pid)
+		}
+	}
+
+	metrics := make([]models.Metric, 0, len(c.watched))
+	for _, watched := range c.watched {
+		data := buildProcessData(ctx, watched, matches[watched])
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricProcess,
+			Value: float64(len(data.PIDs)),
+			Data:  data,
+		})
+	}
+
+	return metrics, nil
+}
+
+// track возвращает процесс с прошлого сбора, если PID не был переиспользован
+func (c *ProcessCollector) track(ctx context.Context, p *psprocess.Process) *trackedProcess {
+	createTime, _ := p.CreateTimeWithContext(ctx)
+	if prev, ok := c.tracked[p.Pid]; ok && prev.createTime == createTime {
+		return prev
+	}
+
+	tracked := &trackedProcess{proc: p, createTime: createTime}
+	c.tracked[p.Pid] = tracked
+	return tracked
+}
+
+func buildProcessData(ctx context.Context, name string, procs []*trackedProcess) models.ProcessData {
+	data := models.ProcessData{
+		Name:   name,
+		Status: StatusNotRunning,
+		PIDs:   []int{},
+	}
+
+	sort.Slice(procs, func(i, j int) bool { return procs[i].proc.Pid < procs[j].proc.Pid })
+
+	for i, tracked := range procs {
+		p := tracked.proc
+		data.PIDs = append(data.PIDs, int(p.Pid))
+
+		if i == 0 {
+			data.PID = int(p.Pid)
+			data.Status = processStatus(ctx, p)
+		}
+
+		data.CPUUsage += tracked.cpuPercent(ctx)
+		if memory, err := p.MemoryInfoWithContext(ctx); err == nil {
+			data.RAMUsage += memory.RSS
+		}
+	}
+
+	data.Running = len(procs) > 0
+	return data
+}
+
+// cpuPercent считает загрузку CPU между двумя сборами. При первом
+// замере разницы еще нет, поэтому берется среднее с момента запуска.
+func (t *trackedProcess) cpuPercent(ctx context.Context) float64 {
+	percent, err := t.proc.PercentWithContext(ctx, 0)
+	if err != nil {
+		return 0
+	}
+	if !t.primed {
+		t.primed = true
+		if avg, err := t.proc.CPUPercentWithContext(ctx); err == nil {
+			return avg
+		}
+	}
+	return percent
+}
+
+func processStatus(ctx context.Context, p *psprocess.Process) string {
+	status, err := p.StatusWithContext(ctx)
+	if err != nil || len(status) == 0 {
+		return "unknown"
+	}
+	return status[0]
+}
+
+func matchName(ctx context.Context, p *psprocess.Process, name, watched string) bool {
+	if name == watched {
+		return true
+	}
+
+	// Имя могло быть обрезано ядром, сверяемся с путем к исполняемому файлу
+	if len(name) == maxCommLength && strings.HasPrefix(watched, name) {
+		if exe, err := p.ExeWithContext(ctx); err == nil && filepath.Base(exe) == watched {
+			return true
+		}
+		if cmdline, err := p.CmdlineSliceWithContext(ctx); err == nil && len(cmdline) > 0 {
+			return filepath.Base(cmdline[0]) == watched
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,152 @@
+package system
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+)
+
+// Окно первого замера: счетчикам ядра нужна разница между двумя чтениями
+const primeWindow = time.Second
+
+// CPUCollector собирает загрузку процессора по разнице счетчиков /proc/stat
+// между сборами: общую, по ядрам и по видам времени
+type CPUCollector struct {
+	mu       sync.Mutex
+	total    *cpu.TimesStat
+	perCore  []cpu.TimesStat
+	switches uint64
+	at       time.Time
+}
+
+func NewCPUCollector() *CPUCollector {
+	return &CPUCollector{}
+}
+
+func (c *CPUCollector) Name() string {
+	return string(models.MetricCPU)
+}
+
+func (c *CPUCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Без прошлого замера делаем два чтения с коротким интервалом
+	if c.total == nil {
+		if err := c.sample(ctx); err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(primeWindow):
+		}
+	}
+
+	prevTotal, prevPerCore, prevSwitches, prevAt := *c.total, c.perCore, c.switches, c.at
+	if err := c.sample(ctx); err != nil {
+		return nil, err
+	}
+
+	data := breakdown(prevTotal, *c.total)
+	for i := range c.perCore {
+		if i >= len(prevPerCore) {
+			break
+		}
+		data.PerCore = append(data.PerCore, breakdown(prevPerCore[i], c.perCore[i]).UsagePercent)
+	}
+
+	if cores, err := cpu.CountsWithContext(ctx, true); err == nil {
+		data.Cores = cores
+	} else {
+		data.Cores = len(c.perCore)
+	}
+
+	if avg, err := load.AvgWithContext(ctx); err == nil {
+		data.Load1 = avg.Load1
+		data.Load5 = avg.Load5
+		data.Load15 = avg.Load15
+	}
+
+	if seconds := c.at.Sub(prevAt).Seconds(); seconds > 0 && c.switches >= prevSwitches {
+		data.ContextSwitchesPerSec = float64(c.switches-prevSwitches) / seconds
+	}
+
+	if uptime, err := host.UptimeWithContext(ctx); err == nil {
+		data.UptimeSeconds = uptime
+	}
+
+	return []models.Metric{
+		{
+			Type:  models.MetricCPU,
+			Value: data.UsagePercent,
+			Data:  data,
+		},
+	}, nil
+}
+
+// sample запоминает текущие счетчики процессора
+func (c *CPUCollector) sample(ctx context.Context) error {
+	total, err := cpu.TimesWithContext(ctx, false)
+	if err != nil {
+		return err
+	}
+	perCore, err := cpu.TimesWithContext(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	c.total = &total[0]
+	c.perCore = perCore
+	c.at = time.Now()
+	if misc, err := load.MiscWithContext(ctx); err == nil {
+		c.switches = uint64(misc.Ctxt)
+	}
+
+	return nil
+}
+
+// breakdown переводит разницу счетчиков в проценты времени. Guest и GuestNice
+// в Linux уже входят в User и Nice, поэтому в сумму не добавляются.
+func breakdown(prev, cur cpu.TimesStat) models.CPUData {
+	delta := func(prev, cur float64) float64 {
+		if cur < prev {
+			return 0
+		}
+		return cur - prev
+	}
+
+	user := delta(prev.User, cur.User)
+	system := delta(prev.System, cur.System)
+	idle := delta(prev.Idle, cur.Idle)
+	nice := delta(prev.Nice, cur.Nice)
+	iowait := delta(prev.Iowait, cur.Iowait)
+	irq := delta(prev.Irq, cur.Irq)
+	softirq := delta(prev.Softirq, cur.Softirq)
+	steal := delta(prev.Steal, cur.Steal)
+
+	total := user + system + idle + nice + iowait + irq + softirq + steal
+	if total <= 0 {
+		return models.CPUData{}
+	}
+
+	percent := func(value float64) float64 {
+		return value / total * 100
+	}
+
+	return models.CPUData{
+		UsagePercent:  percent(total - idle - iowait),
+		UserPercent:   percent(user),
+		SystemPercent: percent(system),
+		NicePercent:   percent(nice),
+		IOWaitPercent: percent(iowait),
+		IRQPercent:    percent(irq + softirq),
+		StealPercent:  percent(steal),
+		IdlePercent:   percent(idle),
+	}
+}
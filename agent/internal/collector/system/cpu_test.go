@@ -0,0 +1,77 @@
+package system
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+func TestBreakdown(t *testing.T) {
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 800, Nice: 10, Iowait: 20, Irq: 5, Softirq: 5, Steal: 10}
+
+	tests := []struct {
+		name string
+		cur  cpu.TimesStat
+		want models.CPUData
+	}{
+		{
+			// За интервал прошло 100 единиц времени
+			name: "busy interval",
+			cur:  cpu.TimesStat{User: 140, System: 60, Idle: 830, Nice: 10, Iowait: 30, Irq: 7, Softirq: 8, Steal: 15},
+			want: models.CPUData{
+				UsagePercent: 60, UserPercent: 40, SystemPercent: 10, IOWaitPercent: 10,
+				IRQPercent: 5, StealPercent: 5, IdlePercent: 30,
+			},
+		},
+		{
+			// Guest уже учтен в User и второй раз не складывается
+			name: "guest time",
+			cur:  cpu.TimesStat{User: 150, System: 50, Idle: 850, Nice: 10, Iowait: 20, Irq: 5, Softirq: 5, Steal: 10, Guest: 50},
+			want: models.CPUData{UsagePercent: 50, UserPercent: 50, IdlePercent: 50},
+		},
+		{
+			name: "zero interval",
+			cur:  prev,
+			want: models.CPUData{},
+		},
+		{
+			// Счетчики сброшены (перезагрузка, горячее подключение ядра): разницы нет
+			name: "counters reset",
+			cur:  cpu.TimesStat{User: 1, System: 1, Idle: 2},
+			want: models.CPUData{},
+		},
+		{
+			// Отступивший назад счетчик не дает отрицательной доли
+			name: "one counter went back",
+			cur:  cpu.TimesStat{User: 150, System: 50, Idle: 700, Nice: 10, Iowait: 20, Irq: 5, Softirq: 5, Steal: 10},
+			want: models.CPUData{UsagePercent: 100, UserPercent: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := breakdown(prev, tt.cur)
+
+			fields := []struct {
+				name      string
+				got, want float64
+			}{
+				{"usage", got.UsagePercent, tt.want.UsagePercent},
+				{"user", got.UserPercent, tt.want.UserPercent},
+				{"system", got.SystemPercent, tt.want.SystemPercent},
+				{"nice", got.NicePercent, tt.want.NicePercent},
+				{"iowait", got.IOWaitPercent, tt.want.IOWaitPercent},
+				{"irq", got.IRQPercent, tt.want.IRQPercent},
+				{"steal", got.StealPercent, tt.want.StealPercent},
+				{"idle", got.IdlePercent, tt.want.IdlePercent},
+			}
+			for _, f := range fields {
+				if math.Abs(f.got-f.want) > 1e-9 || math.IsNaN(f.got) {
+					t.Errorf("%s %v%%, want %v%%", f.name, f.got, f.want)
+				}
+			}
+		})
+	}
+}
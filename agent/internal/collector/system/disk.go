@@ -0,0 +1,115 @@
+package system
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/nekitmilk/agent/internal/hostfs"
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// DiskCollector собирает заполненность смонтированных файловых систем,
+// отобранных по типу и точке монтирования
+type DiskCollector struct {
+	root    hostfs.Root
+	fsTypes *Filter
+	mounts  *Filter
+
+	mu sync.Mutex
+	// Точки монтирования, которые хотя бы раз были доступны на запись:
+	// их переход в режим только для чтения считается перемонтированием
+	writable map[string]bool
+}
+
+// NewDiskCollector создает коллектор. fsTypes отбирает по типу файловой
+// системы, mounts - по точке монтирования на хосте.
+func NewDiskCollector(root hostfs.Root, fsTypes, mounts *Filter) *DiskCollector {
+	return &DiskCollector{
+		root:     root,
+		fsTypes:  fsTypes,
+		mounts:   mounts,
+		writable: make(map[string]bool),
+	}
+}
+
+func (c *DiskCollector) Name() string {
+	return string(models.MetricDisk)
+}
+
+// Collect читает таблицу монтирования хоста (с Root.Context - из
+// /proc/1/mountinfo хоста), а заполненность считает по тем же точкам
+// монтирования внутри корня хоста
+func (c *DiskCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var metrics []models.Metric
+	seen := make(map[string]bool)
+	for _, partition := range partitions {
+		mountpoint := c.root.HostPath(partition.Mountpoint)
+		if !c.fsTypes.Match(partition.Fstype) || !c.mounts.Match(mountpoint) {
+			continue
+		}
+		// Одно устройство, смонтированное несколько раз (bind mount),
+		// учитывается по первой точке монтирования
+		if strings.HasPrefix(partition.Device, "/dev/") {
+			if seen[partition.Device] {
+				continue
+			}
+			seen[partition.Device] = true
+		}
+
+		usage, err := disk.UsageWithContext(ctx, c.root.Path(mountpoint))
+		if err != nil {
+			continue // Пропускаем проблемные разделы
+		}
+
+		readOnly := hasOption(partition.Opts, "ro")
+		remounted := readOnly && c.writable[mountpoint]
+		if !readOnly {
+			c.writable[mountpoint] = true
+		}
+		if remounted {
+			log.Printf("Warning: %s (%s) is mounted read-only", mountpoint, partition.Device)
+		}
+
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricDisk,
+			Value: usage.UsedPercent,
+			Data: models.DiskData{
+				MountPoint:         mountpoint,
+				Device:             partition.Device,
+				FSType:             partition.Fstype,
+				Total:              usage.Total,
+				Used:               usage.Used,
+				Free:               usage.Free,
+				UsagePercent:       usage.UsedPercent,
+				InodesTotal:        usage.InodesTotal,
+				InodesUsed:         usage.InodesUsed,
+				InodesFree:         usage.InodesFree,
+				InodesUsagePercent: usage.InodesUsedPercent,
+				ReadOnly:           readOnly,
+				RemountedReadOnly:  remounted,
+			},
+		})
+	}
+
+	return metrics, nil
+}
+
+func hasOption(opts []string, option string) bool {
+	for _, opt := range opts {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package system
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// DiskIOCollector собирает нагрузку на блочные устройства по разнице
+// счетчиков /proc/diskstats между сборами. Первый сбор только запоминает
+// счетчики.
+type DiskIOCollector struct {
+	devices *Filter
+
+	mu       sync.Mutex
+	previous map[string]disk.IOCountersStat
+	at       time.Time
+}
+
+func NewDiskIOCollector(devices *Filter) *DiskIOCollector {
+	return &DiskIOCollector{devices: devices}
+}
+
+func (c *DiskIOCollector) Name() string {
+	return string(models.MetricDiskIO)
+}
+
+func (c *DiskIOCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	counters, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		if c.devices.Match(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seconds := now.Sub(c.at).Seconds()
+	current := make(map[string]disk.IOCountersStat, len(names))
+	var metrics []models.Metric
+	for _, name := range names {
+		cur := counters[name]
+		current[name] = cur
+
+		prev, ok := c.previous[name]
+		if !ok {
+			continue
+		}
+		data, ok := ioRates(name, prev, cur, seconds)
+		if !ok {
+			continue
+		}
+
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricDiskIO,
+			Value: data.UtilPercent,
+			Data:  data,
+		})
+	}
+	c.previous = current
+	c.at = now
+
+	return metrics, nil
+}
+
+// ioRates считает скорости устройства за интервал. Время в счетчиках ядра -
+// в миллисекундах. Если интервал пуст или счетчик уменьшился, замер пропускается.
+func ioRates(name string, prev, cur disk.IOCountersStat, seconds float64) (models.DiskIOData, bool) {
+	if seconds <= 0 {
+		return models.DiskIOData{}, false
+	}
+	if cur.ReadCount < prev.ReadCount || cur.WriteCount < prev.WriteCount ||
+		cur.ReadBytes < prev.ReadBytes || cur.WriteBytes < prev.WriteBytes ||
+		cur.ReadTime < prev.ReadTime || cur.WriteTime < prev.WriteTime ||
+		cur.IoTime < prev.IoTime {
+		return models.DiskIOData{}, false
+	}
+
+	reads := float64(cur.ReadCount - prev.ReadCount)
+	writes := float64(cur.WriteCount - prev.WriteCount)
+	readTime := float64(cur.ReadTime - prev.ReadTime)
+	writeTime := float64(cur.WriteTime - prev.WriteTime)
+
+	data := models.DiskIOData{
+		Device:           name,
+		ReadIOPS:         reads / seconds,
+		WriteIOPS:        writes / seconds,
+		ReadBytesPerSec:  float64(cur.ReadBytes-prev.ReadBytes) / seconds,
+		WriteBytesPerSec: float64(cur.WriteBytes-prev.WriteBytes) / seconds,
+		UtilPercent:      float64(cur.IoTime-prev.IoTime) / (seconds * 1000) * 100,
+		InProgress:       cur.IopsInProgress,
+		IntervalSeconds:  seconds,
+	}
+	if data.UtilPercent > 100 {
+		data.UtilPercent = 100
+	}
+	if reads > 0 {
+		data.ReadAwaitMs = readTime / reads
+	}
+	if writes > 0 {
+		data.WriteAwaitMs = writeTime / writes
+	}
+	if reads+writes > 0 {
+		data.AwaitMs = (readTime + writeTime) / (reads + writes)
+	}
+
+	return data, true
+}
@@ -0,0 +1,90 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+func TestIORates(t *testing.T) {
+	prev := disk.IOCountersStat{
+		ReadCount: 100, WriteCount: 200, ReadBytes: 1 << 20, WriteBytes: 2 << 20,
+		ReadTime: 500, WriteTime: 1000, IoTime: 3000,
+	}
+	// grow возвращает счетчики, выросшие на delta
+	grow := func(delta disk.IOCountersStat) disk.IOCountersStat {
+		return disk.IOCountersStat{
+			ReadCount:      prev.ReadCount + delta.ReadCount,
+			WriteCount:     prev.WriteCount + delta.WriteCount,
+			ReadBytes:      prev.ReadBytes + delta.ReadBytes,
+			WriteBytes:     prev.WriteBytes + delta.WriteBytes,
+			ReadTime:       prev.ReadTime + delta.ReadTime,
+			WriteTime:      prev.WriteTime + delta.WriteTime,
+			IoTime:         prev.IoTime + delta.IoTime,
+			IopsInProgress: delta.IopsInProgress,
+		}
+	}
+	// reset возвращает выросшие счетчики, в которых field сброшен
+	reset := func(field func(c *disk.IOCountersStat)) disk.IOCountersStat {
+		c := grow(disk.IOCountersStat{ReadCount: 10, WriteCount: 10, ReadBytes: 10, WriteBytes: 10, ReadTime: 10, WriteTime: 10, IoTime: 10})
+		field(&c)
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		cur     disk.IOCountersStat
+		seconds float64
+		ok      bool
+		want    models.DiskIOData
+	}{
+		{
+			name: "load",
+			cur: grow(disk.IOCountersStat{
+				ReadCount: 50, WriteCount: 100, ReadBytes: 400 << 10, WriteBytes: 800 << 10,
+				ReadTime: 250, WriteTime: 1000, IoTime: 5000, IopsInProgress: 3,
+			}),
+			seconds: 10,
+			ok:      true,
+			want: models.DiskIOData{
+				Device: "sda", ReadIOPS: 5, WriteIOPS: 10, ReadBytesPerSec: 40 << 10, WriteBytesPerSec: 80 << 10,
+				ReadAwaitMs: 5, WriteAwaitMs: 10, AwaitMs: 1250.0 / 150, UtilPercent: 50, InProgress: 3, IntervalSeconds: 10,
+			},
+		},
+		{
+			// Без запросов время ожидания не делится на ноль
+			name:    "idle device",
+			cur:     prev,
+			seconds: 10,
+			ok:      true,
+			want:    models.DiskIOData{Device: "sda", IntervalSeconds: 10},
+		},
+		{
+			// Счетчики опрашиваются неровно, доля занятости не выходит за 100%
+			name:    "util capped",
+			cur:     grow(disk.IOCountersStat{IoTime: 12000}),
+			seconds: 10,
+			ok:      true,
+			want:    models.DiskIOData{Device: "sda", UtilPercent: 100, IntervalSeconds: 10},
+		},
+		{name: "zero interval", cur: grow(disk.IOCountersStat{ReadCount: 10}), seconds: 0},
+		{name: "clock went back", cur: grow(disk.IOCountersStat{ReadCount: 10}), seconds: -1},
+		{name: "read count wrapped", cur: reset(func(c *disk.IOCountersStat) { c.ReadCount = 1 }), seconds: 10},
+		{name: "write bytes wrapped", cur: reset(func(c *disk.IOCountersStat) { c.WriteBytes = 0 }), seconds: 10},
+		{name: "read time wrapped", cur: reset(func(c *disk.IOCountersStat) { c.ReadTime = 0 }), seconds: 10},
+		{name: "io time reset", cur: reset(func(c *disk.IOCountersStat) { c.IoTime = 0 }), seconds: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ioRates("sda", prev, tt.cur, tt.seconds)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("ioRates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
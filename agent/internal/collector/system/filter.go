@@ -0,0 +1,59 @@
+package system
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter отбирает имена по регулярным выражениям: имя проходит, если
+// подходит под одно из include (пустой список - под любое) и ни под одно
+// из exclude
+type Filter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func NewFilter(include, exclude []string) (*Filter, error) {
+	f := &Filter{}
+	var err error
+	if f.include, err = compile(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compile(exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Filter) Match(name string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, name) {
+		return false
+	}
+	return !matchAny(f.exclude, name)
+}
+
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchAny(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
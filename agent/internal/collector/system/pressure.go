@@ -0,0 +1,117 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nekitmilk/agent/internal/hostfs"
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// Ресурсы, для которых ядро публикует PSI
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// PressureCollector читает pressure stall information из /proc/pressure:
+// долю времени, когда задачи простаивали в ожидании процессора, памяти
+// или ввода-вывода. Требует ядро 4.20+ с включенным PSI.
+type PressureCollector struct {
+	root hostfs.Root
+}
+
+func NewPressureCollector(root hostfs.Root) *PressureCollector {
+	return &PressureCollector{root: root}
+}
+
+func (c *PressureCollector) Name() string {
+	return string(models.MetricPressure)
+}
+
+func (c *PressureCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	var metrics []models.Metric
+	for _, resource := range pressureResources {
+		data, err := readPressure(c.root.Path("proc", "pressure", resource))
+		if err != nil {
+			// Ядро без PSI - не ошибка, метрик просто нет
+			if psiUnavailable(err) {
+				continue
+			}
+			return metrics, fmt.Errorf("failed to read %s pressure: %w", resource, err)
+		}
+		data.Resource = resource
+
+		metrics = append(metrics, models.Metric{
+			Type:  models.MetricPressure,
+			Value: data.SomeAvg10,
+			Data:  data,
+		})
+	}
+
+	return metrics, nil
+}
+
+// psiUnavailable сообщает, что ядро не публикует PSI: файлов нет (ядро старше
+// 4.20 или без CONFIG_PSI) или они есть, но чтение отвечает EOPNOTSUPP (psi=0)
+func psiUnavailable(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.EOPNOTSUPP)
+}
+
+// readPressure разбирает файл вида
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readPressure(path string) (models.PressureData, error) {
+	var data models.PressureData
+
+	file, err := os.Open(path)
+	if err != nil {
+		return data, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var avg10, avg60, avg300 *float64
+		var total *uint64
+		switch fields[0] {
+		case "some":
+			avg10, avg60, avg300, total = &data.SomeAvg10, &data.SomeAvg60, &data.SomeAvg300, &data.SomeTotal
+		case "full":
+			avg10, avg60, avg300, total = &data.FullAvg10, &data.FullAvg60, &data.FullAvg300, &data.FullTotal
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "avg10":
+				*avg10, err = strconv.ParseFloat(value, 64)
+			case "avg60":
+				*avg60, err = strconv.ParseFloat(value, 64)
+			case "avg300":
+				*avg300, err = strconv.ParseFloat(value, 64)
+			case "total":
+				*total, err = strconv.ParseUint(value, 10, 64)
+			}
+			if err != nil {
+				return data, fmt.Errorf("invalid %s: %w", field, err)
+			}
+		}
+	}
+
+	return data, scanner.Err()
+}
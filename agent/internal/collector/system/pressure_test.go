@@ -0,0 +1,92 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+func TestReadPressure(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    models.PressureData
+		wantErr bool
+	}{
+		{
+			name: "memory",
+			content: "some avg10=1.50 avg60=0.75 avg300=0.25 total=123456\n" +
+				"full avg10=0.50 avg60=0.10 avg300=0.05 total=6543\n",
+			want: models.PressureData{
+				SomeAvg10: 1.5, SomeAvg60: 0.75, SomeAvg300: 0.25, SomeTotal: 123456,
+				FullAvg10: 0.5, FullAvg60: 0.1, FullAvg300: 0.05, FullTotal: 6543,
+			},
+		},
+		{
+			// Ядра до 5.13 пишут для cpu только строку some
+			name:    "cpu without full",
+			content: "some avg10=12.00 avg60=8.00 avg300=4.00 total=999\n",
+			want:    models.PressureData{SomeAvg10: 12, SomeAvg60: 8, SomeAvg300: 4, SomeTotal: 999},
+		},
+		{
+			// total - накопительный счетчик в микросекундах, доходит до конца uint64
+			name:    "total at uint64 limit",
+			content: "some avg10=0.00 avg60=0.00 avg300=0.00 total=18446744073709551615\n",
+			want:    models.PressureData{SomeTotal: 18446744073709551615},
+		},
+		{
+			name:    "unknown lines and fields",
+			content: "\nsome avg10=2.00 extra avg600=9.00 total=7\nhalf avg10=99.00\n",
+			want:    models.PressureData{SomeAvg10: 2, SomeTotal: 7},
+		},
+		{name: "empty file"},
+		{name: "invalid average", content: "some avg10=high avg60=0.00 avg300=0.00 total=0\n", wantErr: true},
+		{name: "negative total", content: "some avg10=0.00 avg60=0.00 avg300=0.00 total=-1\n", wantErr: true},
+		{name: "total overflow", content: "full avg10=0.00 avg60=0.00 avg300=0.00 total=18446744073709551616\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "memory")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write %s: %v", path, err)
+			}
+
+			got, err := readPressure(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPressure error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("readPressure = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPSIUnavailable(t *testing.T) {
+	_, missing := readPressure(filepath.Join(t.TempDir(), "cpu"))
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"file missing", missing, true},
+		// Ядро с psi=0: файл есть, чтение отвечает EOPNOTSUPP
+		{"psi disabled", &fs.PathError{Op: "read", Path: "/proc/pressure/cpu", Err: syscall.EOPNOTSUPP}, true},
+		{"permission denied", &fs.PathError{Op: "open", Path: "/proc/pressure/cpu", Err: syscall.EACCES}, false},
+		{"parse error", fmt.Errorf("invalid avg10=x: %w", errors.New("syntax")), false},
+	}
+
+	for _, tt := range tests {
+		if got := psiUnavailable(tt.err); got != tt.want {
+			t.Errorf("%s: psiUnavailable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
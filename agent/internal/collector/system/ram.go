@@ -0,0 +1,78 @@
+package system
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// RAMCollector собирает использование оперативной памяти и подкачки
+type RAMCollector struct {
+	mu sync.Mutex
+	// Счетчики подкачки с прошлого сбора: скорость считается по разнице
+	swapIn  uint64
+	swapOut uint64
+	at      time.Time
+}
+
+func NewRAMCollector() *RAMCollector {
+	return &RAMCollector{}
+}
+
+func (c *RAMCollector) Name() string {
+	return string(models.MetricRAM)
+}
+
+func (c *RAMCollector) Collect(ctx context.Context) ([]models.Metric, error) {
+	memory, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := models.RAMData{
+		Total:        memory.Total,
+		Used:         memory.Used,
+		UsagePercent: memory.UsedPercent,
+		Available:    memory.Available,
+		Free:         memory.Free,
+		Buffers:      memory.Buffers,
+		Cached:       memory.Cached,
+		SwapTotal:    memory.SwapTotal,
+		SwapUsed:     memory.SwapTotal - memory.SwapFree,
+		SwapCached:   memory.SwapCached,
+	}
+	if data.SwapTotal > 0 {
+		data.SwapUsagePercent = float64(data.SwapUsed) / float64(data.SwapTotal) * 100
+	}
+
+	if swap, err := mem.SwapMemoryWithContext(ctx); err == nil {
+		c.swapRates(&data, swap.Sin, swap.Sout)
+	}
+
+	return []models.Metric{
+		{
+			Type:  models.MetricRAM,
+			Value: memory.UsedPercent,
+			Data:  data,
+		},
+	}, nil
+}
+
+// swapRates считает скорость подкачки между сборами. Первый сбор только
+// запоминает счетчики.
+func (c *RAMCollector) swapRates(data *models.RAMData, swapIn, swapOut uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if seconds := now.Sub(c.at).Seconds(); !c.at.IsZero() && seconds > 0 &&
+		swapIn >= c.swapIn && swapOut >= c.swapOut {
+		data.SwapInBytesPerSec = float64(swapIn-c.swapIn) / seconds
+		data.SwapOutBytesPerSec = float64(swapOut-c.swapOut) / seconds
+	}
+
+	c.swapIn, c.swapOut, c.at = swapIn, swapOut, now
+}
@@ -8,9 +8,106 @@ import (
 
 type Config struct {
 	MonitoringCenterURL string        `env:"MONITORING_CENTER_URL" default:"http://localhost:8080"`
-	HostID              string        `env:"HOST_ID" required:"true"`
+	HostID              string        `env:"HOST_ID"`
 	PollingInterval     time.Duration `env:"POLLING_INTERVAL" default:"5m"`
 	RequestTimeout      time.Duration `env:"REQUEST_TIMEOUT" default:"30s"`
+
+	// mTLS с ЦМ (MONITORING_CENTER_URL с https://): TLS_CA_FILE закрепляет CA
+	// ЦМ вместо системных, TLS_CERT_FILE и TLS_KEY_FILE - сертификат агента,
+	// выпущенный для его HOST_ID. TLS_SERVER_NAME переопределяет имя сервера
+	// при проверке сертификата ЦМ.
+	TLSCAFile     string `env:"TLS_CA_FILE"`
+	TLSCertFile   string `env:"TLS_CERT_FILE"`
+	TLSKeyFile    string `env:"TLS_KEY_FILE"`
+	TLSServerName string `env:"TLS_SERVER_NAME"`
+
+	// Секрет ключа агента, который ЦМ выдает при создании хоста. Задается
+	// вместе с HOST_ID, при регистрации по ENROLL_TOKEN берется из IDENTITY_FILE.
+	AgentKey string `env:"AGENT_KEY"`
+
+	// Регистрация агента без HOST_ID: агент отправляет ЦМ имя хоста, адреса
+	// и сводку об оборудовании по ENROLL_TOKEN и сохраняет полученные ID хоста
+	// и ключ в IDENTITY_FILE. HOST_IP задает основной адрес хоста, по умолчанию -
+	// адрес, с которого агент обращается к ЦМ.
+	EnrollToken    string   `env:"ENROLL_TOKEN"`
+	IdentityFile   string   `env:"IDENTITY_FILE" envDefault:"/var/lib/monitoring-agent/identity.json"`
+	HostIP         string   `env:"HOST_IP"`
+	EnrollPriority int      `env:"ENROLL_PRIORITY"`
+	EnrollLabels   []string `env:"ENROLL_LABELS" envSeparator:","`
+
+	// Точка монтирования корня хоста, когда агент запущен в контейнере.
+	// Все коллекторы читают /proc, /sys, /etc и таблицу монтирования хоста
+	// из нее, а пути в метриках указываются относительно хоста.
+	HostRoot string `env:"HOST_ROOT" envDefault:"/"`
+
+	// Имена процессов, за которыми следит агент (через запятую)
+	WatchProcesses []string `env:"WATCH_PROCESSES" envSeparator:","`
+
+	// Сокет Docker Engine API и имена контейнеров, за которыми следит агент
+	DockerSocket    string   `env:"DOCKER_SOCKET" envDefault:"/var/run/docker.sock"`
+	WatchContainers []string `env:"WATCH_CONTAINERS" envSeparator:","`
+
+	// Как часто агент проверяет, не изменилась ли его конфигурация в ЦМ.
+	// Списки из ЦМ имеют приоритет над WATCH_PROCESSES и WATCH_CONTAINERS.
+	ConfigPollInterval time.Duration `env:"CONFIG_POLL_INTERVAL" envDefault:"1m"`
+
+	// Роль хоста (master/backup) в группе ROLE_GROUP (имя или ID, пусто - группа
+	// по умолчанию). Роль публикуется в файле состояния и по HTTP на
+	// ROLE_LISTEN_ADDR (пусто - не слушать), при смене роли запускаются скрипты.
+	RoleGroup         string        `env:"ROLE_GROUP"`
+	RolePollInterval  time.Duration `env:"ROLE_POLL_INTERVAL" envDefault:"15s"`
+	RoleStateFile     string        `env:"ROLE_STATE_FILE" envDefault:"/var/lib/monitoring-agent/role.json"`
+	RoleListenAddr    string        `env:"ROLE_LISTEN_ADDR" envDefault:"127.0.0.1:9110"`
+	PromoteScript     string        `env:"ON_PROMOTE_SCRIPT"`
+	DemoteScript      string        `env:"ON_DEMOTE_SCRIPT"`
+	RoleScriptTimeout time.Duration `env:"ROLE_SCRIPT_TIMEOUT" envDefault:"30s"`
+
+	// Очередь пакетов метрик, которые не удалось отправить в ЦМ. Пакеты
+	// отправляются повторно по порядку, когда ЦМ снова доступен. При
+	// превышении SPOOL_MAX_BYTES вытесняются самые старые, пакеты старше
+	// SPOOL_MAX_AGE отбрасываются. Пустой SPOOL_DIR отключает очередь.
+	SpoolDir      string        `env:"SPOOL_DIR" envDefault:"/var/lib/monitoring-agent/spool"`
+	SpoolMaxBytes int64         `env:"SPOOL_MAX_BYTES" envDefault:"67108864"`
+	SpoolMaxAge   time.Duration `env:"SPOOL_MAX_AGE" envDefault:"24h"`
+
+	// Первый цикл каждой задачи сдвигается на случайную задержку до
+	// SCHEDULE_JITTER, чтобы агенты не обращались к ЦМ одновременно
+	ScheduleJitter time.Duration `env:"SCHEDULE_JITTER" envDefault:"30s"`
+
+	// Расписания коллекторов: COLLECTOR_<ИМЯ>_ENABLED, _INTERVAL, _TIMEOUT
+	CPU       CollectorConfig `envPrefix:"COLLECTOR_CPU_"`
+	RAM       CollectorConfig `envPrefix:"COLLECTOR_RAM_"`
+	Disk      CollectorConfig `envPrefix:"COLLECTOR_DISK_"`
+	Process   CollectorConfig `envPrefix:"COLLECTOR_PROCESS_"`
+	Port      CollectorConfig `envPrefix:"COLLECTOR_PORT_"`
+	Container CollectorConfig `envPrefix:"COLLECTOR_CONTAINER_"`
+	Network   CollectorConfig `envPrefix:"COLLECTOR_NETWORK_"`
+	Pressure  CollectorConfig `envPrefix:"COLLECTOR_PRESSURE_"`
+	DiskIO    CollectorConfig `envPrefix:"COLLECTOR_DISK_IO_"`
+
+	// Регулярные выражения имен интерфейсов, которые не учитываются в
+	// сетевых метриках (через запятую). По умолчанию - loopback и виртуальные.
+	NetworkExclude []string `env:"NETWORK_EXCLUDE" envSeparator:"," envDefault:"^lo$,^veth,^docker,^br-,^virbr,^vnet,^cni,^flannel,^cali,^tun,^tap,^ifb"`
+
+	// Отбор файловых систем для метрик диска: регулярные выражения типов
+	// и точек монтирования (через запятую). Пустой список включения - все.
+	// По умолчанию исключаются псевдо- и виртуальные файловые системы
+	// и служебные точки монтирования контейнеров.
+	DiskFSTypes        []string `env:"DISK_FSTYPES" envSeparator:","`
+	DiskExcludeFSTypes []string `env:"DISK_EXCLUDE_FSTYPES" envSeparator:"," envDefault:"^(tmpfs|devtmpfs|ramfs|overlay|aufs|squashfs|proc|sysfs|cgroup2?|nsfs|autofs|devpts|mqueue|tracefs|debugfs|securityfs|pstore|bpf|fusectl|configfs|hugetlbfs|efivarfs|binfmt_misc|rpc_pipefs|selinuxfs|fuse\\.lxcfs)$"`
+	DiskMounts         []string `env:"DISK_MOUNTS" envSeparator:","`
+	DiskExcludeMounts  []string `env:"DISK_EXCLUDE_MOUNTS" envSeparator:"," envDefault:"^/(proc|sys|dev|run)(/|$),^/var/lib/(docker|containerd|kubelet)/,^/snap/,^/etc/(hostname|hosts|resolv\\.conf)$"`
+
+	// Блочные устройства, не учитываемые в метриках ввода-вывода. По умолчанию
+	// исключаются виртуальные устройства и разделы: нагрузка считается по дискам.
+	DiskIOExcludeDevices []string `env:"DISK_IO_EXCLUDE_DEVICES" envSeparator:"," envDefault:"^(loop|ram|zram|sr|fd)[0-9]+$,^(sd|vd|xvd|hd)[a-z]+[0-9]+$,^nvme[0-9]+n[0-9]+p[0-9]+$,^mmcblk[0-9]+p[0-9]+$"`
+}
+
+// CollectorConfig расписание одного коллектора. Нулевой интервал - POLLING_INTERVAL.
+type CollectorConfig struct {
+	Enabled  bool          `env:"ENABLED" envDefault:"true"`
+	Interval time.Duration `env:"INTERVAL"`
+	Timeout  time.Duration `env:"TIMEOUT" envDefault:"30s"`
 }
 
 func Load() (*Config, error) {
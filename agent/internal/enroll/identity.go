@@ -0,0 +1,70 @@
+package enroll
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// LoadIdentity читает сохраненную регистрацию. Если файла нет, возвращает nil без ошибки.
+func LoadIdentity(path string) (*models.Identity, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity: %w", err)
+	}
+
+	var identity models.Identity
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return nil, fmt.Errorf("failed to decode identity: %w", err)
+	}
+	if identity.HostID == "" {
+		return nil, fmt.Errorf("identity %s has no host ID", path)
+	}
+
+	return &identity, nil
+}
+
+// SaveIdentity атомарно сохраняет регистрацию. Файл содержит секрет агента,
+// поэтому доступен только владельцу.
+func SaveIdentity(path string, identity *models.Identity) error {
+	data, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create identity directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".identity-*")
+	if err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package enroll
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/hostfs"
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// Hostname возвращает имя хоста. В контейнере имя берется из /etc/hostname
+// хоста, потому что у контейнера свое имя.
+func Hostname(root hostfs.Root) (string, error) {
+	if !root.IsHost() {
+		if data, err := os.ReadFile(root.Path("etc", "hostname")); err == nil {
+			if name := strings.TrimSpace(string(data)); name != "" {
+				return name, nil
+			}
+		}
+	}
+	return os.Hostname()
+}
+
+// IPs возвращает адреса хоста, первым - основной. Основной адрес - hostIP,
+// если он задан, иначе адрес, с которого агент обращается к ЦМ.
+// Loopback и link-local адреса пропускаются.
+func IPs(hostIP, centerURL string) []string {
+	var ips []string
+	seen := make(map[string]bool)
+	add := func(ip net.IP) {
+		if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			return
+		}
+		if s := ip.String(); !seen[s] {
+			seen[s] = true
+			ips = append(ips, s)
+		}
+	}
+
+	add(net.ParseIP(hostIP))
+	add(outboundIP(centerURL))
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ips
+	}
+	// Сначала IPv4: ими хосты обычно добавлялись в ЦМ вручную
+	for _, v4 := range []bool{true, false} {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && (ipNet.IP.To4() != nil) == v4 {
+				add(ipNet.IP)
+			}
+		}
+	}
+
+	return ips
+}
+
+// outboundIP определяет локальный адрес маршрута до ЦМ. UDP-сокет
+// только выбирает маршрут, пакеты не отправляются.
+func outboundIP(centerURL string) net.IP {
+	u, err := url.Parse(centerURL)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+
+	conn, err := net.DialTimeout("udp", net.JoinHostPort(u.Hostname(), port), 5*time.Second)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
+// Inventory собирает сводку об оборудовании. ctx должен содержать пути
+// к файловой системе хоста (hostfs.Root.Context). Недоступные сведения
+// остаются пустыми.
+func Inventory(ctx context.Context) models.Hardware {
+	hardware := models.Hardware{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+
+	if info, err := host.InfoWithContext(ctx); err == nil {
+		hardware.OS = info.OS
+		hardware.Platform = info.Platform
+		hardware.PlatformVersion = info.PlatformVersion
+		hardware.KernelVersion = info.KernelVersion
+		if info.KernelArch != "" {
+			hardware.Arch = info.KernelArch
+		}
+	}
+
+	if infos, err := cpu.InfoWithContext(ctx); err == nil && len(infos) > 0 {
+		hardware.CPUModel = infos[0].ModelName
+	}
+	if cores, err := cpu.CountsWithContext(ctx, true); err == nil {
+		hardware.CPUCores = cores
+	}
+
+	if memory, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		hardware.MemoryTotal = memory.Total
+	}
+
+	return hardware
+}
@@ -0,0 +1,73 @@
+package hostfs
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/common"
+)
+
+// Root корень файловой системы хоста. Агент в контейнере видит хост
+// смонтированным, например, в /host: тогда /proc, /sys и /etc хоста
+// читаются из /host/proc, /host/sys и /host/etc, а пути, которые
+// попадают в метрики, переводятся обратно в пути хоста.
+type Root struct {
+	path string
+}
+
+// New создает корень. Пустой путь означает, что агент запущен на самом хосте.
+func New(path string) Root {
+	path = filepath.Clean(path)
+	if path == "." || path == "" {
+		path = "/"
+	}
+	return Root{path: path}
+}
+
+// IsHost сообщает, что агент читает файловую систему хоста напрямую
+func (r Root) IsHost() bool {
+	return r.path == "/"
+}
+
+func (r Root) String() string {
+	return r.path
+}
+
+// Path возвращает путь к файлу хоста: Path("proc", "stat") -> /host/proc/stat
+func (r Root) Path(elem ...string) string {
+	return filepath.Join(append([]string{r.path}, elem...)...)
+}
+
+// HostPath переводит путь внутри агента в путь на хосте: /host/data -> /data.
+// Пути вне корня возвращаются без изменений.
+func (r Root) HostPath(path string) string {
+	if r.IsHost() {
+		return path
+	}
+	if path == r.path {
+		return "/"
+	}
+	if rest, ok := strings.CutPrefix(path, r.path+"/"); ok {
+		return "/" + rest
+	}
+	return path
+}
+
+// Context передает gopsutil пути к /proc, /sys и остальным каталогам хоста.
+// Все вызовы gopsutil с этим контекстом читают данные хоста, а не контейнера.
+func (r Root) Context(ctx context.Context) context.Context {
+	if r.IsHost() {
+		return ctx
+	}
+
+	return context.WithValue(ctx, common.EnvKey, common.EnvMap{
+		common.HostRootEnvKey: r.path,
+		common.HostProcEnvKey: r.Path("proc"),
+		common.HostSysEnvKey:  r.Path("sys"),
+		common.HostEtcEnvKey:  r.Path("etc"),
+		common.HostVarEnvKey:  r.Path("var"),
+		common.HostRunEnvKey:  r.Path("run"),
+		common.HostDevEnvKey:  r.Path("dev"),
+	})
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Hardware сводка об оборудовании и ОС хоста
+type Hardware struct {
+	OS              string `json:"os"`
+	Platform        string `json:"platform"`
+	PlatformVersion string `json:"platform_version"`
+	KernelVersion   string `json:"kernel_version"`
+	Arch            string `json:"arch"`
+	CPUModel        string `json:"cpu_model"`
+	CPUCores        int    `json:"cpu_cores"`
+	MemoryTotal     uint64 `json:"memory_total"`
+}
+
+// EnrollRequest запрос на регистрацию хоста в ЦМ по bootstrap-токену.
+// Первый адрес в IPs становится IP хоста.
+type EnrollRequest struct {
+	Token    string   `json:"token"`
+	Hostname string   `json:"hostname"`
+	IPs      []string `json:"ips"`
+	Hardware Hardware `json:"hardware"`
+	Priority int      `json:"priority,omitempty"`
+	Labels   []string `json:"labels,omitempty"`
+}
+
+// EnrollResponse идентификатор хоста и ключ агента, выданные ЦМ
+type EnrollResponse struct {
+	HostID       string `json:"host_id"`
+	CredentialID string `json:"credential_id"`
+	Secret       string `json:"secret"`
+}
+
+// Identity то, что агент сохраняет после регистрации, чтобы не
+// регистрироваться повторно при перезапуске
+type Identity struct {
+	HostID       string    `json:"host_id"`
+	CredentialID string    `json:"credential_id"`
+	Secret       string    `json:"secret"`
+	EnrolledAt   time.Time `json:"enrolled_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// HostConfig конфигурация мониторинга хоста, получаемая из ЦМ
+type HostConfig struct {
+	HostID     string        `json:"host_id"`
+	Version    int64         `json:"version"` // 0 - конфигурация в ЦМ не задавалась
+	Processes  []string      `json:"processes"`
+	Containers []string      `json:"containers"`
+	Ports      []WatchedPort `json:"ports"`
+}
+
+type WatchedPort struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"` // "tcp" или "udp"
+}
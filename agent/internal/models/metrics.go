@@ -13,6 +13,10 @@ const (
 	MetricProcess   MetricType = "process"
 	MetricPort      MetricType = "port"
 	MetricContainer MetricType = "container"
+	MetricAgent     MetricType = "agent"
+	MetricNetwork   MetricType = "network"
+	MetricPressure  MetricType = "pressure"
+	MetricDiskIO    MetricType = "disk_io"
 )
 
 type Metric struct {
@@ -30,36 +34,114 @@ type MetricsRequest struct {
 
 // Детальные структуры данных (аналогичные ЦМ)
 type CPUData struct {
-	UsagePercent float64 `bson:"usage_percent" json:"usage_percent"`
-	Cores        int     `bson:"cores" json:"cores"`
+	UsagePercent float64   `bson:"usage_percent" json:"usage_percent"`
+	Cores        int       `bson:"cores" json:"cores"`                           // Логические ядра
+	PerCore      []float64 `bson:"per_core,omitempty" json:"per_core,omitempty"` // Загрузка каждого ядра, %
+	// Доли времени процессора за интервал между замерами, %
+	UserPercent   float64 `bson:"user_percent" json:"user_percent"`
+	SystemPercent float64 `bson:"system_percent" json:"system_percent"`
+	NicePercent   float64 `bson:"nice_percent" json:"nice_percent"`
+	IOWaitPercent float64 `bson:"iowait_percent" json:"iowait_percent"`
+	IRQPercent    float64 `bson:"irq_percent" json:"irq_percent"` // Аппаратные и программные прерывания
+	StealPercent  float64 `bson:"steal_percent" json:"steal_percent"`
+	IdlePercent   float64 `bson:"idle_percent" json:"idle_percent"`
+	// Средняя длина очереди выполнения за 1, 5 и 15 минут
+	Load1                 float64 `bson:"load1" json:"load1"`
+	Load5                 float64 `bson:"load5" json:"load5"`
+	Load15                float64 `bson:"load15" json:"load15"`
+	ContextSwitchesPerSec float64 `bson:"context_switches_per_sec" json:"context_switches_per_sec"`
+	UptimeSeconds         uint64  `bson:"uptime_seconds" json:"uptime_seconds"`
 }
 
 type RAMData struct {
 	Total        uint64  `bson:"total" json:"total"`
 	Used         uint64  `bson:"used" json:"used"`
 	UsagePercent float64 `bson:"usage_percent" json:"usage_percent"`
+	Available    uint64  `bson:"available" json:"available"` // Можно выделить без подкачки, с учетом кэша
+	Free         uint64  `bson:"free" json:"free"`
+	Buffers      uint64  `bson:"buffers" json:"buffers"`
+	Cached       uint64  `bson:"cached" json:"cached"`
+	SwapTotal    uint64  `bson:"swap_total" json:"swap_total"`
+	SwapUsed     uint64  `bson:"swap_used" json:"swap_used"`
+	SwapCached   uint64  `bson:"swap_cached" json:"swap_cached"`
+	// Процент использования подкачки, 0 если ее нет
+	SwapUsagePercent float64 `bson:"swap_usage_percent" json:"swap_usage_percent"`
+	// Скорость подкачки между замерами
+	SwapInBytesPerSec  float64 `bson:"swap_in_bytes_per_sec" json:"swap_in_bytes_per_sec"`
+	SwapOutBytesPerSec float64 `bson:"swap_out_bytes_per_sec" json:"swap_out_bytes_per_sec"`
+}
+
+// PressureData pressure stall information ресурса (cpu, memory, io).
+// some - доля времени, когда хотя бы одна задача ждала ресурс,
+// full - когда ждали все задачи. avg - проценты за 10, 60 и 300 секунд,
+// total - суммарное время ожидания в микросекундах.
+// Value метрики - some avg10.
+type PressureData struct {
+	Resource   string  `bson:"resource" json:"resource"`
+	SomeAvg10  float64 `bson:"some_avg10" json:"some_avg10"`
+	SomeAvg60  float64 `bson:"some_avg60" json:"some_avg60"`
+	SomeAvg300 float64 `bson:"some_avg300" json:"some_avg300"`
+	SomeTotal  uint64  `bson:"some_total" json:"some_total"`
+	FullAvg10  float64 `bson:"full_avg10" json:"full_avg10"`
+	FullAvg60  float64 `bson:"full_avg60" json:"full_avg60"`
+	FullAvg300 float64 `bson:"full_avg300" json:"full_avg300"`
+	FullTotal  uint64  `bson:"full_total" json:"full_total"`
 }
 
 type DiskData struct {
-	MountPoint   string  `bson:"mount_point" json:"mount_point"`
-	Total        uint64  `bson:"total" json:"total"`
-	Used         uint64  `bson:"used" json:"used"`
-	Free         uint64  `bson:"free" json:"free"`
-	UsagePercent float64 `bson:"usage_percent" json:"usage_percent"`
+	MountPoint         string  `bson:"mount_point" json:"mount_point"`
+	Device             string  `bson:"device" json:"device"`
+	FSType             string  `bson:"fs_type" json:"fs_type"`
+	Total              uint64  `bson:"total" json:"total"`
+	Used               uint64  `bson:"used" json:"used"`
+	Free               uint64  `bson:"free" json:"free"`
+	UsagePercent       float64 `bson:"usage_percent" json:"usage_percent"`
+	InodesTotal        uint64  `bson:"inodes_total" json:"inodes_total"`
+	InodesUsed         uint64  `bson:"inodes_used" json:"inodes_used"`
+	InodesFree         uint64  `bson:"inodes_free" json:"inodes_free"`
+	InodesUsagePercent float64 `bson:"inodes_usage_percent" json:"inodes_usage_percent"`
+	ReadOnly           bool    `bson:"read_only" json:"read_only"`
+	// Файловая система была доступна на запись, а теперь смонтирована только
+	// для чтения (обычно ядро так реагирует на ошибки диска)
+	RemountedReadOnly bool `bson:"remounted_read_only" json:"remounted_read_only"`
+}
+
+// DiskIOData нагрузка на блочное устройство за интервал между замерами.
+// Value метрики - UtilPercent.
+type DiskIOData struct {
+	Device           string  `bson:"device" json:"device"`
+	ReadIOPS         float64 `bson:"read_iops" json:"read_iops"`
+	WriteIOPS        float64 `bson:"write_iops" json:"write_iops"`
+	ReadBytesPerSec  float64 `bson:"read_bytes_per_sec" json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `bson:"write_bytes_per_sec" json:"write_bytes_per_sec"`
+	// Среднее время выполнения запроса, включая ожидание в очереди, мс
+	AwaitMs      float64 `bson:"await_ms" json:"await_ms"`
+	ReadAwaitMs  float64 `bson:"read_await_ms" json:"read_await_ms"`
+	WriteAwaitMs float64 `bson:"write_await_ms" json:"write_await_ms"`
+	// Доля времени, когда устройство было занято запросами, %
+	UtilPercent     float64 `bson:"util_percent" json:"util_percent"`
+	InProgress      uint64  `bson:"in_progress" json:"in_progress"` // Запросов в обработке на момент замера
+	IntervalSeconds float64 `bson:"interval_seconds" json:"interval_seconds"`
 }
 
 type ProcessData struct {
 	Name     string  `bson:"name" json:"name"`
-	PID      int     `bson:"pid" json:"pid"`
+	Running  bool    `bson:"running" json:"running"`
+	PID      int     `bson:"pid" json:"pid"`   // Основной (наименьший) PID
+	PIDs     []int   `bson:"pids" json:"pids"` // Все экземпляры процесса
 	Status   string  `bson:"status" json:"status"`
-	CPUUsage float64 `bson:"cpu_usage" json:"cpu_usage"`
-	RAMUsage uint64  `bson:"ram_usage" json:"ram_usage"`
+	CPUUsage float64 `bson:"cpu_usage" json:"cpu_usage"` // Суммарно по всем экземплярам
+	RAMUsage uint64  `bson:"ram_usage" json:"ram_usage"` // RSS, суммарно по всем экземплярам
 }
 
 type PortData struct {
 	Port     int    `bson:"port" json:"port"`
-	Protocol string `bson:"protocol" json:"protocol"`
-	Status   string `bson:"status" json:"status"` // "open", "closed", "filtered"
+	Protocol string `bson:"protocol" json:"protocol"` // "tcp", "tcp6", "udp", "udp6"
+	Address  string `bson:"address" json:"address"`
+	Status   string `bson:"status" json:"status"`                   // "open", "closed", "filtered"
+	State    string `bson:"state,omitempty" json:"state,omitempty"` // Состояние сокета: "LISTEN", "UNCONN"
+	PID      int    `bson:"pid,omitempty" json:"pid,omitempty"`
+	Process  string `bson:"process,omitempty" json:"process,omitempty"`
 	Service  string `bson:"service,omitempty" json:"service,omitempty"`
 }
 
@@ -69,4 +151,31 @@ type ContainerData struct {
 	Image  string `bson:"image" json:"image"`
 	Status string `bson:"status" json:"status"`
 	State  string `bson:"state" json:"state"` // "running", "exited", etc.
+	// Контейнер указан в конфигурации хоста, но не найден в Docker
+	Missing bool `bson:"missing" json:"missing"`
+}
+
+// NetworkData скорости сетевого интерфейса за интервал между замерами.
+// Value метрики - суммарная скорость приема и передачи, байт/с.
+type NetworkData struct {
+	Interface       string  `bson:"interface" json:"interface"`
+	RxBytesPerSec   float64 `bson:"rx_bytes_per_sec" json:"rx_bytes_per_sec"`
+	TxBytesPerSec   float64 `bson:"tx_bytes_per_sec" json:"tx_bytes_per_sec"`
+	RxPacketsPerSec float64 `bson:"rx_packets_per_sec" json:"rx_packets_per_sec"`
+	TxPacketsPerSec float64 `bson:"tx_packets_per_sec" json:"tx_packets_per_sec"`
+	RxErrorsPerSec  float64 `bson:"rx_errors_per_sec" json:"rx_errors_per_sec"`
+	TxErrorsPerSec  float64 `bson:"tx_errors_per_sec" json:"tx_errors_per_sec"`
+	RxDropsPerSec   float64 `bson:"rx_drops_per_sec" json:"rx_drops_per_sec"`
+	TxDropsPerSec   float64 `bson:"tx_drops_per_sec" json:"tx_drops_per_sec"`
+	IntervalSeconds float64 `bson:"interval_seconds" json:"interval_seconds"` // Длина интервала между замерами
+}
+
+// AgentData состояние самого агента. Value метрики - DroppedBatches.
+// Счетчики ведутся с момента запуска агента.
+type AgentData struct {
+	SpoolBatches   int    `bson:"spool_batches" json:"spool_batches"`     // Пакетов ждут отправки на диске
+	SpoolBytes     int64  `bson:"spool_bytes" json:"spool_bytes"`         // Размер очереди на диске
+	DroppedBatches uint64 `bson:"dropped_batches" json:"dropped_batches"` // Потеряно пакетов
+	SkippedCycles  uint64 `bson:"skipped_cycles" json:"skipped_cycles"`   // Циклы, пропущенные из-за незавершенного предыдущего
+	OverrunCycles  uint64 `bson:"overrun_cycles" json:"overrun_cycles"`   // Циклы дольше своего интервала
 }
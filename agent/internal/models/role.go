@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Role string
+
+const (
+	RoleMaster Role = "master"
+	RoleBackup Role = "backup"
+	// Роль неизвестна: ЦМ еще не ответил или аренда мастера истекла без связи с ЦМ
+	RoleUnknown Role = "unknown"
+)
+
+// AgentRole роль хоста в группе, получаемая из ЦМ
+type AgentRole struct {
+	HostID         string     `json:"host_id"`
+	GroupID        string     `json:"group_id"`
+	GroupName      string     `json:"group_name"`
+	Role           Role       `json:"role"`
+	Term           int64      `json:"term"`
+	MasterHostID   *string    `json:"master_host_id"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+}
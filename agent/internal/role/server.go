@@ -0,0 +1,50 @@
+package role
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// Serve отдает роль по HTTP до отмены ctx:
+//
+//	GET /role   - State в JSON
+//	GET /master - 200, если хост мастер, иначе 503 (для проверок балансировщика)
+func Serve(ctx context.Context, addr string, watcher *Watcher) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /role", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(watcher.State())
+	})
+
+	mux.HandleFunc("GET /master", func(w http.ResponseWriter, r *http.Request) {
+		state := watcher.State()
+		if state.Role != models.RoleMaster {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte(string(state.Role) + "\n"))
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving role on %s", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Role endpoint failed: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package role
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+	"github.com/nekitmilk/agent/internal/sender"
+)
+
+// State текущая роль хоста, как ее видит агент
+type State struct {
+	Role           models.Role `json:"role"`
+	Group          string      `json:"group"`
+	Term           int64       `json:"term"`
+	MasterHostID   *string     `json:"master_host_id"`
+	LeaseExpiresAt *time.Time  `json:"lease_expires_at"`
+	ChangedAt      time.Time   `json:"changed_at"`      // Когда роль сменилась последний раз
+	CheckedAt      *time.Time  `json:"checked_at"`      // Последний успешный ответ ЦМ
+	Error          string      `json:"error,omitempty"` // Ошибка последнего опроса ЦМ
+}
+
+// Hooks скрипты, которые запускаются при смене роли, как notify_master и
+// notify_backup в keepalived. Скрипт выполняется через /bin/sh -c и получает
+// ROLE, PREVIOUS_ROLE, MASTER_TERM, HOST_ID и GROUP в окружении.
+type Hooks struct {
+	Promote string
+	Demote  string
+	Timeout time.Duration
+}
+
+// Watcher опрашивает ЦМ, является ли хост мастером своей группы,
+// публикует роль в файле состояния и запускает скрипты при ее смене
+type Watcher struct {
+	sender    *sender.HTTPSender
+	hostID    string
+	group     string
+	interval  time.Duration
+	stateFile string
+	hooks     Hooks
+
+	mu    sync.RWMutex
+	state State
+}
+
+func NewWatcher(sender *sender.HTTPSender, hostID, group string, interval time.Duration, stateFile string, hooks Hooks) *Watcher {
+	return &Watcher{
+		sender:    sender,
+		hostID:    hostID,
+		group:     group,
+		interval:  interval,
+		stateFile: stateFile,
+		hooks:     hooks,
+		state: State{
+			Role:      models.RoleUnknown,
+			Group:     group,
+			ChangedAt: time.Now(),
+		},
+	}
+}
+
+// State возвращает копию текущего состояния
+func (w *Watcher) State() State {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.state
+}
+
+// Run опрашивает ЦМ с заданным интервалом до отмены ctx
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.poll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context) {
+	now := time.Now()
+	role, err := w.sender.FetchRole(w.hostID, w.group)
+
+	w.mu.Lock()
+	prev := w.state
+	next := prev
+
+	if err != nil {
+		next.Error = err.Error()
+		// Без связи с ЦМ мастер остается мастером до конца аренды, затем
+		// понижается, чтобы не оказаться вторым мастером после перевыборов
+		if prev.Role == models.RoleMaster && prev.LeaseExpiresAt != nil && now.After(*prev.LeaseExpiresAt) {
+			next.Role = models.RoleUnknown
+			next.Error = fmt.Sprintf("master lease expired without contact to center: %v", err)
+		}
+	} else {
+		next.Role = role.Role
+		next.Group = role.GroupName
+		next.Term = role.Term
+		next.MasterHostID = role.MasterHostID
+		next.LeaseExpiresAt = role.LeaseExpiresAt
+		next.CheckedAt = &now
+		next.Error = ""
+	}
+
+	// Смена term при сохранении роли master значит, что между опросами
+	// мастер успел смениться и вернуться: скрипт повышения нужен с новым term
+	promoted := next.Role == models.RoleMaster && (prev.Role != models.RoleMaster || next.Term != prev.Term)
+	demoted := prev.Role == models.RoleMaster && next.Role != models.RoleMaster
+	if next.Role != prev.Role {
+		next.ChangedAt = now
+	}
+
+	w.state = next
+	w.mu.Unlock()
+
+	if err != nil && prev.Error == "" {
+		log.Printf("Failed to fetch role: %v", err)
+	}
+	if next.Role != prev.Role || next.Term != prev.Term {
+		log.Printf("Role in group %q: %s -> %s (term %d)", next.Group, prev.Role, next.Role, next.Term)
+	}
+
+	switch {
+	case promoted:
+		w.runHook(ctx, w.hooks.Promote, prev, next)
+	case demoted:
+		w.runHook(ctx, w.hooks.Demote, prev, next)
+	}
+
+	if err := w.saveState(next); err != nil {
+		log.Printf("Failed to write role state file: %v", err)
+	}
+}
+
+func (w *Watcher) runHook(ctx context.Context, script string, prev, next State) {
+	if script == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.hooks.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", script)
+	cmd.Env = append(os.Environ(),
+		"ROLE="+string(next.Role),
+		"PREVIOUS_ROLE="+string(prev.Role),
+		"MASTER_TERM="+strconv.FormatInt(next.Term, 10),
+		"HOST_ID="+w.hostID,
+		"GROUP="+next.Group,
+	)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		log.Printf("Role script %q failed: %v: %s", script, err, bytes.TrimSpace(output.Bytes()))
+		return
+	}
+
+	log.Printf("Role script %q finished: %s", script, bytes.TrimSpace(output.Bytes()))
+}
+
+// saveState атомарно перезаписывает файл состояния
+func (w *Watcher) saveState(state State) error {
+	if w.stateFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(w.stateFile)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".role-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), w.stateFile)
+}
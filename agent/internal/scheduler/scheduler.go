@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats счетчики циклов задачи с момента запуска
+type Stats struct {
+	Runs         uint64
+	Skipped      uint64 // Тик пропущен: прошлый цикл еще не закончился
+	Overruns     uint64 // Цикл длился дольше интервала
+	LastDuration time.Duration
+}
+
+type task struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+
+	running atomic.Bool
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Scheduler запускает периодические задачи так, что два цикла одной задачи
+// никогда не выполняются одновременно. Первый цикл каждой задачи сдвигается
+// на случайную задержку до maxJitter, чтобы агенты, перезапущенные вместе,
+// не обращались к ЦМ в один и тот же момент.
+type Scheduler struct {
+	maxJitter time.Duration
+	tasks     []*task
+}
+
+func New(maxJitter time.Duration) *Scheduler {
+	return &Scheduler{maxJitter: maxJitter}
+}
+
+// Add регистрирует задачу. Задачи добавляются до вызова Run.
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context)) {
+	s.tasks = append(s.tasks, &task{name: name, interval: interval, run: run})
+}
+
+// Run выполняет задачи до отмены ctx и ждет завершения начатых циклов
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, t := range s.tasks {
+		wg.Add(1)
+		go func(t *task) {
+			defer wg.Done()
+			s.loop(ctx, t, &wg)
+		}(t)
+	}
+	wg.Wait()
+}
+
+// Stats возвращает счетчики всех задач
+func (s *Scheduler) Stats() map[string]Stats {
+	stats := make(map[string]Stats, len(s.tasks))
+	for _, t := range s.tasks {
+		t.mu.Lock()
+		stats[t.name] = t.stats
+		t.mu.Unlock()
+	}
+	return stats
+}
+
+func (s *Scheduler) loop(ctx context.Context, t *task, wg *sync.WaitGroup) {
+	if !sleep(ctx, s.jitter(t.interval)) {
+		return
+	}
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	s.start(ctx, t, wg)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.start(ctx, t, wg)
+		}
+	}
+}
+
+// start запускает цикл задачи, если предыдущий уже закончился
+func (s *Scheduler) start(ctx context.Context, t *task, wg *sync.WaitGroup) {
+	if !t.running.CompareAndSwap(false, true) {
+		t.mu.Lock()
+		t.stats.Skipped++
+		t.mu.Unlock()
+		log.Printf("Skipping %s cycle: previous cycle is still running", t.name)
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer t.running.Store(false)
+
+		started := time.Now()
+		t.run(ctx)
+		duration := time.Since(started)
+
+		t.mu.Lock()
+		t.stats.Runs++
+		t.stats.LastDuration = duration
+		overrun := duration > t.interval
+		if overrun {
+			t.stats.Overruns++
+		}
+		t.mu.Unlock()
+
+		if overrun {
+			log.Printf("Cycle of %s took %v, longer than its interval %v", t.name, duration.Round(time.Millisecond), t.interval)
+		}
+	}()
+}
+
+// jitter возвращает случайную задержку первого цикла, не больше интервала задачи
+func (s *Scheduler) jitter(interval time.Duration) time.Duration {
+	limit := s.maxJitter
+	if interval < limit {
+		limit = interval
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit)))
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor ждет выполнения условия, опрашивая его до истечения timeout
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSlowTaskIsSkippedNotOverlapped(t *testing.T) {
+	const interval = 5 * time.Millisecond
+
+	var active, maxActive atomic.Int32
+	release := make(chan struct{})
+
+	s := New(0)
+	s.Add("slow", interval, func(ctx context.Context) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	// Пока первый цикл висит, тики должны пропускаться
+	waitFor(t, 5*time.Second, func() bool { return s.Stats()["slow"].Skipped >= 3 })
+
+	stats := s.Stats()["slow"]
+	if stats.Runs != 0 {
+		t.Errorf("runs %d before the first cycle finished, want 0", stats.Runs)
+	}
+
+	close(release)
+	waitFor(t, 5*time.Second, func() bool { return s.Stats()["slow"].Runs >= 2 })
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	stats = s.Stats()["slow"]
+	if maxActive.Load() != 1 {
+		t.Errorf("max concurrent cycles %d, want 1", maxActive.Load())
+	}
+	if stats.Overruns < 1 {
+		t.Errorf("overruns %d, want at least 1", stats.Overruns)
+	}
+	if stats.LastDuration <= 0 {
+		t.Errorf("last duration %v, want positive", stats.LastDuration)
+	}
+	if active.Load() != 0 {
+		t.Errorf("%d cycles still running after Run returned", active.Load())
+	}
+}
+
+func TestFastTaskNeitherSkippedNorOverrun(t *testing.T) {
+	s := New(0)
+	s.Add("fast", 20*time.Millisecond, func(ctx context.Context) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	waitFor(t, 5*time.Second, func() bool { return s.Stats()["fast"].Runs >= 3 })
+
+	stats := s.Stats()["fast"]
+	if stats.Skipped != 0 || stats.Overruns != 0 {
+		t.Errorf("stats %+v, want no skipped or overrun cycles", stats)
+	}
+}
+
+func TestRunWaitsForStartedCycles(t *testing.T) {
+	var finished atomic.Bool
+	started := make(chan struct{})
+	var once sync.Once
+
+	s := New(0)
+	s.Add("task", time.Hour, func(ctx context.Context) {
+		once.Do(func() { close(started) })
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	cancel()
+	<-done
+
+	if !finished.Load() {
+		t.Error("Run returned before the started cycle finished")
+	}
+}
+
+func TestJitterBound(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxJitter time.Duration
+		interval  time.Duration
+		limit     time.Duration
+	}{
+		{"jitter below interval", 10 * time.Millisecond, time.Second, 10 * time.Millisecond},
+		{"interval below jitter", time.Minute, 30 * time.Millisecond, 30 * time.Millisecond},
+		{"equal", 50 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond},
+		{"one nanosecond", time.Nanosecond, time.Second, time.Nanosecond},
+		{"no jitter", 0, time.Second, 0},
+		{"negative jitter", -time.Second, time.Second, 0},
+		{"zero interval", time.Second, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.maxJitter)
+			for i := 0; i < 10000; i++ {
+				d := s.jitter(tt.interval)
+				if d < 0 {
+					t.Fatalf("jitter %v is negative", d)
+				}
+				if tt.limit == 0 && d != 0 {
+					t.Fatalf("jitter %v, want 0", d)
+				}
+				if tt.limit > 0 && d >= tt.limit {
+					t.Fatalf("jitter %v exceeds min(maxJitter, interval) = %v", d, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestFirstCycleDelayedByJitter(t *testing.T) {
+	const interval = 40 * time.Millisecond
+
+	var first atomic.Int64
+	s := New(time.Hour)
+	s.Add("task", interval, func(ctx context.Context) {
+		first.CompareAndSwap(0, time.Now().UnixNano())
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	go s.Run(ctx)
+
+	// Задержка первого цикла ограничена интервалом, а не maxJitter
+	waitFor(t, 5*time.Second, func() bool { return first.Load() != 0 })
+	if delay := time.Duration(first.Load() - start.UnixNano()); delay > interval+time.Second {
+		t.Errorf("first cycle delayed by %v, want at most about %v", delay, interval)
+	}
+}
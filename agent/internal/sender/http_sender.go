@@ -2,27 +2,55 @@ package sender
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/nekitmilk/agent/internal/models"
 )
 
+// Заголовок, в котором ЦМ ожидает секрет ключа агента
+const agentKeyHeader = "X-Agent-Key"
+
 type HTTPSender struct {
-	baseURL string
-	timeout time.Duration
-	client  *http.Client
+	baseURL  string
+	timeout  time.Duration
+	client   *http.Client
+	agentKey string
 }
 
-func NewHTTPSender(baseURL string, timeout time.Duration) *HTTPSender {
+// NewHTTPSender создает клиент ЦМ. tlsConfig задает закрепленный CA ЦМ
+// и клиентский сертификат агента, nil - настройки по умолчанию.
+func NewHTTPSender(baseURL string, timeout time.Duration, tlsConfig *tls.Config) *HTTPSender {
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
+
 	return &HTTPSender{
 		baseURL: baseURL,
 		timeout: timeout,
-		client: &http.Client{
-			Timeout: timeout,
-		},
+		client:  client,
+	}
+}
+
+// SetAgentKey задает секрет ключа, которым подписываются запросы агента.
+// Вызывается до начала отправки.
+func (s *HTTPSender) SetAgentKey(key string) {
+	s.agentKey = key
+}
+
+func (s *HTTPSender) authorize(req *http.Request) {
+	if s.agentKey != "" {
+		req.Header.Set(agentKeyHeader, s.agentKey)
 	}
 }
 
@@ -39,6 +67,7 @@ func (s *HTTPSender) SendMetrics(batch models.MetricsRequest) error {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	s.authorize(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -47,8 +76,155 @@ func (s *HTTPSender) SendMetrics(batch models.MetricsRequest) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	return nil
 }
+
+// StatusError неожиданный код ответа ЦМ
+type StatusError struct {
+	StatusCode int
+	// Через сколько ЦМ просит повторить запрос (429 и 503 с Retry-After), 0 - не указано
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("unexpected status code: %d, retry after %v", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Rejected сообщает, что ЦМ отклонил содержимое пакета и повторять отправку
+// бессмысленно. Ошибки авторизации и перегрузки ЦМ сюда не относятся.
+func (e *StatusError) Rejected() bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter разбирает заголовок Retry-After: число секунд или HTTP-дату
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
+
+// Enroll регистрирует хост в ЦМ по bootstrap-токену
+func (s *HTTPSender) Enroll(request models.EnrollRequest) (*models.EnrollResponse, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal enroll request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/agents/enroll", s.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enroll: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var enrolled models.EnrollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&enrolled); err != nil {
+		return nil, fmt.Errorf("failed to decode enroll response: %w", err)
+	}
+
+	return &enrolled, nil
+}
+
+// FetchHostConfig запрашивает конфигурацию хоста из ЦМ. Если etag совпадает
+// с текущей версией в ЦМ, возвращает nil без ошибки.
+func (s *HTTPSender) FetchHostConfig(hostID, etag string) (*models.HostConfig, string, error) {
+	url := fmt.Sprintf("%s/api/agents/%s/config", s.baseURL, hostID)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	s.authorize(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, nil
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var config models.HostConfig
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, "", fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	return &config, resp.Header.Get("ETag"), nil
+}
+
+// FetchRole запрашивает роль хоста в группе. Пустая группа - группа по умолчанию.
+func (s *HTTPSender) FetchRole(hostID, group string) (*models.AgentRole, error) {
+	endpoint := fmt.Sprintf("%s/api/agents/%s/role", s.baseURL, hostID)
+	if group != "" {
+		endpoint += "?group=" + url.QueryEscape(group)
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	s.authorize(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch role: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var role models.AgentRole
+	if err := json.NewDecoder(resp.Body).Decode(&role); err != nil {
+		return nil, fmt.Errorf("failed to decode role: %w", err)
+	}
+
+	return &role, nil
+}
@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestSendMetricsStatusError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       time.Duration
+		rejected   bool
+	}{
+		{"queue full", http.StatusTooManyRequests, "7", 7 * time.Second, false},
+		{"shutting down", http.StatusServiceUnavailable, "1", time.Second, false},
+		{"overloaded without header", http.StatusTooManyRequests, "", 0, false},
+		{"bad request", http.StatusBadRequest, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			s := NewHTTPSender(server.URL, time.Second, nil)
+			err := s.SendMetrics(models.MetricsRequest{HostID: "host", Timestamp: time.Now()})
+
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("SendMetrics error %v, want *StatusError", err)
+			}
+			if statusErr.StatusCode != tt.status || statusErr.RetryAfter != tt.want || statusErr.Rejected() != tt.rejected {
+				t.Errorf("got %+v rejected=%v, want status %d retry after %v rejected=%v",
+					statusErr, statusErr.Rejected(), tt.status, tt.want, tt.rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+// ErrRejected оборачивается функцией отправки, когда ЦМ отклонил пакет
+// окончательно: такой пакет удаляется из очереди, а не отправляется повторно
+var ErrRejected = errors.New("batch rejected")
+
+const fileExt = ".json"
+
+// Spool очередь неотправленных пакетов метрик на диске. Каждый пакет хранится
+// в отдельном файле, имя которого задает порядок отправки. Размер очереди
+// ограничен maxBytes, пакеты старше maxAge не отправляются. Вытесненные
+// и просроченные пакеты учитываются в счетчике Dropped.
+type Spool struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+
+	mu      sync.Mutex
+	files   []segment // По возрастанию имени, то есть от старых к новым
+	size    int64
+	seq     uint64
+	dropped uint64
+
+	replayMu sync.Mutex
+}
+
+type segment struct {
+	name string
+	size int64
+}
+
+// Stats состояние очереди для метрики агента
+type Stats struct {
+	Batches int
+	Bytes   int64
+	Dropped uint64
+}
+
+// Open открывает очередь в dir, подхватывая пакеты, оставшиеся с прошлого запуска
+func Open(dir string, maxBytes int64, maxAge time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	s := &Spool{dir: dir, maxBytes: maxBytes, maxAge: maxAge}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileExt) {
+			continue
+		}
+		// Недописанные временные файлы остаются после аварийного завершения
+		if strings.HasPrefix(name, ".") {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		s.files = append(s.files, segment{name: name, size: info.Size()})
+		s.size += info.Size()
+	}
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].name < s.files[j].name })
+
+	s.mu.Lock()
+	s.enforceLimitLocked()
+	s.mu.Unlock()
+
+	return s, nil
+}
+
+// Push сохраняет пакет в конец очереди. Если очередь переполнена,
+// вытесняются самые старые пакеты.
+func (s *Spool) Push(batch models.MetricsRequest) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Время пакета в начале имени сохраняет порядок и между перезапусками агента
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d%s", batch.Timestamp.UnixNano(), s.seq%1000000, fileExt)
+
+	if err := writeFile(s.dir, name, data); err != nil {
+		return fmt.Errorf("failed to write batch: %w", err)
+	}
+
+	s.files = append(s.files, segment{name: name, size: int64(len(data))})
+	s.size += int64(len(data))
+	sort.SliceStable(s.files, func(i, j int) bool { return s.files[i].name < s.files[j].name })
+	s.enforceLimitLocked()
+
+	return nil
+}
+
+// Replay отправляет пакеты от старых к новым и удаляет отправленные.
+// Останавливается на первой ошибке отправки, кроме ErrRejected.
+// Одновременно выполняется только один Replay: повторный вызов сразу
+// возвращает nil. Возвращает число отправленных пакетов.
+func (s *Spool) Replay(send func(models.MetricsRequest) error) (int, error) {
+	if !s.replayMu.TryLock() {
+		return 0, nil
+	}
+	defer s.replayMu.Unlock()
+
+	sent := 0
+	for {
+		s.mu.Lock()
+		if len(s.files) == 0 {
+			s.mu.Unlock()
+			return sent, nil
+		}
+		head := s.files[0]
+		s.mu.Unlock()
+
+		batch, err := s.read(head.name)
+		if err != nil {
+			log.Printf("Dropping unreadable spooled batch %s: %v", head.name, err)
+			s.drop(head.name)
+			continue
+		}
+
+		if s.maxAge > 0 && time.Since(batch.Timestamp) > s.maxAge {
+			s.drop(head.name)
+			continue
+		}
+
+		if err := send(batch); err != nil {
+			if errors.Is(err, ErrRejected) {
+				log.Printf("Dropping spooled batch %s: %v", head.name, err)
+				s.drop(head.name)
+				continue
+			}
+			return sent, err
+		}
+
+		s.remove(head.name, false)
+		sent++
+	}
+}
+
+// Len возвращает число пакетов в очереди
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.files)
+}
+
+// Stats возвращает размер очереди и число потерянных пакетов с момента запуска
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{Batches: len(s.files), Bytes: s.size, Dropped: s.dropped}
+}
+
+func (s *Spool) read(name string) (models.MetricsRequest, error) {
+	var batch models.MetricsRequest
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return batch, err
+	}
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return batch, err
+	}
+
+	return batch, nil
+}
+
+func (s *Spool) drop(name string) {
+	s.remove(name, true)
+}
+
+func (s *Spool) remove(name string, dropped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.files {
+		if f.name != name {
+			continue
+		}
+		os.Remove(filepath.Join(s.dir, name))
+		s.files = append(s.files[:i], s.files[i+1:]...)
+		s.size -= f.size
+		if dropped {
+			s.dropped++
+		}
+		return
+	}
+}
+
+// enforceLimitLocked вытесняет старые пакеты, пока очередь не уложится в maxBytes.
+// Последний записанный пакет сохраняется, даже если он один больше лимита.
+func (s *Spool) enforceLimitLocked() {
+	if s.maxBytes <= 0 {
+		return
+	}
+
+	for s.size > s.maxBytes && len(s.files) > 1 {
+		oldest := s.files[0]
+		os.Remove(filepath.Join(s.dir, oldest.name))
+		s.files = s.files[1:]
+		s.size -= oldest.size
+		s.dropped++
+	}
+}
+
+// writeFile атомарно записывает файл через временный файл и переименование
+func writeFile(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, ".batch-*"+fileExt)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
@@ -0,0 +1,313 @@
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nekitmilk/agent/internal/models"
+)
+
+func testBatch(hostID string, timestamp time.Time) models.MetricsRequest {
+	return models.MetricsRequest{
+		HostID:    hostID,
+		Metrics:   []models.Metric{{Type: models.MetricCPU, Value: 42, Timestamp: timestamp}},
+		Timestamp: timestamp,
+	}
+}
+
+func batchSize(t *testing.T, batch models.MetricsRequest) int64 {
+	t.Helper()
+	data, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatalf("failed to marshal batch: %v", err)
+	}
+	return int64(len(data))
+}
+
+func openSpool(t *testing.T, dir string, maxBytes int64, maxAge time.Duration) *Spool {
+	t.Helper()
+	s, err := Open(dir, maxBytes, maxAge)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return s
+}
+
+func push(t *testing.T, s *Spool, batches ...models.MetricsRequest) {
+	t.Helper()
+	for _, batch := range batches {
+		if err := s.Push(batch); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+}
+
+// replayAll досылает всю очередь и возвращает идентификаторы отправленных пакетов по порядку
+func replayAll(t *testing.T, s *Spool) []string {
+	t.Helper()
+	var sent []string
+	n, err := s.Replay(func(batch models.MetricsRequest) error {
+		sent = append(sent, batch.HostID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if n != len(sent) {
+		t.Fatalf("Replay returned %d, sent %d", n, len(sent))
+	}
+	return sent
+}
+
+func dirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func equal(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+func TestFilenameOrdering(t *testing.T) {
+	dir := t.TempDir()
+	s := openSpool(t, dir, 0, 0)
+
+	base := time.Now()
+	// Пакеты ставятся не по порядку времени, отправляться должны по времени
+	push(t, s,
+		testBatch("b", base.Add(2*time.Second)),
+		testBatch("a", base.Add(time.Second)),
+		testBatch("c", base.Add(3*time.Second)),
+	)
+
+	names := dirFiles(t, dir)
+	if len(names) != 3 {
+		t.Fatalf("got %d files, want 3: %v", len(names), names)
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, fileExt) || strings.HasPrefix(name, ".") {
+			t.Errorf("unexpected file %q", name)
+		}
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("files not sorted: %v", names)
+	}
+
+	// Очередь переживает перезапуск и сохраняет порядок
+	reopened := openSpool(t, dir, 0, 0)
+	if got := replayAll(t, reopened); !equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("replay order %v, want [a b c]", got)
+	}
+	if reopened.Len() != 0 || len(dirFiles(t, dir)) != 0 {
+		t.Errorf("spool not empty after replay: %d batches, files %v", reopened.Len(), dirFiles(t, dir))
+	}
+}
+
+func TestSameTimestampKeepsPushOrder(t *testing.T) {
+	s := openSpool(t, t.TempDir(), 0, 0)
+
+	now := time.Now()
+	push(t, s, testBatch("first", now), testBatch("second", now), testBatch("third", now))
+
+	if got := replayAll(t, s); !equal(got, []string{"first", "second", "third"}) {
+		t.Errorf("replay order %v, want [first second third]", got)
+	}
+}
+
+func TestAtomicWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	// Временный файл, оставшийся после аварийного завершения во время записи
+	leftover := filepath.Join(dir, ".batch-123"+fileExt)
+	if err := os.WriteFile(leftover, []byte(`{"host_id":`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	s := openSpool(t, dir, 0, 0)
+	if s.Len() != 0 {
+		t.Errorf("temporary file loaded as a batch")
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("temporary file not removed on open: %v", err)
+	}
+
+	batch := testBatch("host", time.Now())
+	push(t, s, batch)
+
+	names := dirFiles(t, dir)
+	if len(names) != 1 || strings.HasPrefix(names[0], ".") {
+		t.Fatalf("got files %v, want a single batch file without temporaries", names)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var stored models.MetricsRequest
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("stored batch is not valid JSON: %v", err)
+	}
+	if stored.HostID != batch.HostID || len(stored.Metrics) != 1 || !stored.Timestamp.Equal(batch.Timestamp) {
+		t.Errorf("stored batch %+v, want %+v", stored, batch)
+	}
+}
+
+func TestEnforceLimitEvictsOldest(t *testing.T) {
+	base := time.Now()
+	batches := []models.MetricsRequest{
+		testBatch("1", base.Add(time.Second)),
+		testBatch("2", base.Add(2*time.Second)),
+		testBatch("3", base.Add(3*time.Second)),
+		testBatch("4", base.Add(4*time.Second)),
+	}
+	size := batchSize(t, batches[0])
+
+	tests := []struct {
+		name        string
+		maxBytes    int64
+		wantSent    []string
+		wantDropped uint64
+	}{
+		{"unlimited", 0, []string{"1", "2", "3", "4"}, 0},
+		{"fits all", 4 * size, []string{"1", "2", "3", "4"}, 0},
+		{"fits two", 2*size + size/2, []string{"3", "4"}, 2},
+		// Последний пакет сохраняется, даже если он один больше лимита
+		{"smaller than one batch", size / 2, []string{"4"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openSpool(t, dir, tt.maxBytes, 0)
+			push(t, s, batches...)
+
+			stats := s.Stats()
+			if stats.Batches != len(tt.wantSent) || stats.Dropped != tt.wantDropped {
+				t.Errorf("stats %+v, want %d batches and %d dropped", stats, len(tt.wantSent), tt.wantDropped)
+			}
+			if stats.Bytes != int64(len(tt.wantSent))*size {
+				t.Errorf("stats bytes %d, want %d", stats.Bytes, int64(len(tt.wantSent))*size)
+			}
+			if files := dirFiles(t, dir); len(files) != len(tt.wantSent) {
+				t.Errorf("got %d files on disk, want %d", len(files), len(tt.wantSent))
+			}
+			if got := replayAll(t, s); !equal(got, tt.wantSent) {
+				t.Errorf("sent %v, want %v", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestOpenEnforcesLimit(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now()
+	batches := []models.MetricsRequest{
+		testBatch("1", base.Add(time.Second)),
+		testBatch("2", base.Add(2*time.Second)),
+		testBatch("3", base.Add(3*time.Second)),
+	}
+	push(t, openSpool(t, dir, 0, 0), batches...)
+
+	// Лимит уменьшили между запусками агента
+	s := openSpool(t, dir, batchSize(t, batches[0]), 0)
+	if stats := s.Stats(); stats.Batches != 1 || stats.Dropped != 2 {
+		t.Errorf("stats %+v, want 1 batch and 2 dropped", stats)
+	}
+	if got := replayAll(t, s); !equal(got, []string{"3"}) {
+		t.Errorf("sent %v, want [3]", got)
+	}
+}
+
+func TestReplayDropsExpired(t *testing.T) {
+	s := openSpool(t, t.TempDir(), 0, time.Hour)
+
+	now := time.Now()
+	push(t, s,
+		testBatch("old", now.Add(-2*time.Hour)),
+		testBatch("fresh", now.Add(-time.Minute)),
+		testBatch("stale", now.Add(-90*time.Minute)),
+	)
+
+	if got := replayAll(t, s); !equal(got, []string{"fresh"}) {
+		t.Errorf("sent %v, want [fresh]", got)
+	}
+	if stats := s.Stats(); stats.Batches != 0 || stats.Dropped != 2 {
+		t.Errorf("stats %+v, want empty spool and 2 dropped", stats)
+	}
+}
+
+func TestReplayDropsRejected(t *testing.T) {
+	s := openSpool(t, t.TempDir(), 0, 0)
+
+	base := time.Now()
+	push(t, s,
+		testBatch("ok-1", base.Add(time.Second)),
+		testBatch("bad", base.Add(2*time.Second)),
+		testBatch("ok-2", base.Add(3*time.Second)),
+	)
+
+	var sent []string
+	n, err := s.Replay(func(batch models.MetricsRequest) error {
+		if batch.HostID == "bad" {
+			return fmt.Errorf("%w: unexpected status code: 400", ErrRejected)
+		}
+		sent = append(sent, batch.HostID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if n != 2 || !equal(sent, []string{"ok-1", "ok-2"}) {
+		t.Errorf("sent %d %v, want 2 [ok-1 ok-2]", n, sent)
+	}
+	if stats := s.Stats(); stats.Batches != 0 || stats.Dropped != 1 {
+		t.Errorf("stats %+v, want empty spool and 1 dropped", stats)
+	}
+}
+
+func TestReplayStopsOnSendError(t *testing.T) {
+	s := openSpool(t, t.TempDir(), 0, 0)
+
+	base := time.Now()
+	push(t, s,
+		testBatch("1", base.Add(time.Second)),
+		testBatch("2", base.Add(2*time.Second)),
+		testBatch("3", base.Add(3*time.Second)),
+	)
+
+	unavailable := errors.New("connection refused")
+	n, err := s.Replay(func(batch models.MetricsRequest) error {
+		if batch.HostID == "2" {
+			return unavailable
+		}
+		return nil
+	})
+	if !errors.Is(err, unavailable) {
+		t.Fatalf("Replay error %v, want %v", err, unavailable)
+	}
+	if n != 1 {
+		t.Errorf("Replay sent %d, want 1", n)
+	}
+	// Неотправленные пакеты остаются в очереди и не считаются потерянными
+	if stats := s.Stats(); stats.Batches != 2 || stats.Dropped != 0 {
+		t.Errorf("stats %+v, want 2 batches and 0 dropped", stats)
+	}
+	if got := replayAll(t, s); !equal(got, []string{"2", "3"}) {
+		t.Errorf("sent %v, want [2 3]", got)
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS host_watched_ports CASCADE;
+DROP TABLE IF EXISTS host_watched_containers CASCADE;
+DROP TABLE IF EXISTS host_watched_processes CASCADE;
+DROP TABLE IF EXISTS host_configs CASCADE;
@@ -0,0 +1,26 @@
+CREATE TABLE host_configs (
+    host_id UUID PRIMARY KEY REFERENCES hosts(id) ON DELETE CASCADE,
+    version BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE host_watched_processes (
+    host_id UUID NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (host_id, name)
+);
+CREATE TABLE host_watched_containers (
+    host_id UUID NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (host_id, name)
+);
+CREATE TABLE host_watched_ports (
+    host_id UUID NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    port INTEGER NOT NULL CHECK (
+        port BETWEEN 1 AND 65535
+    ),
+    protocol VARCHAR(3) NOT NULL CHECK (protocol IN ('tcp', 'udp')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (host_id, port, protocol)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS host_status_history CASCADE;
+ALTER TABLE hosts DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS last_seen_at;
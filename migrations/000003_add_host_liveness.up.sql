@@ -0,0 +1,12 @@
+ALTER TABLE hosts
+ADD COLUMN last_seen_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN status_changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+CREATE TABLE host_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    host_id UUID NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    old_status VARCHAR(20) NOT NULL,
+    new_status VARCHAR(20) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_host_status_history_host ON host_status_history(host_id, changed_at DESC);
+CREATE INDEX idx_hosts_last_seen_at ON hosts(last_seen_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS alert_rules CASCADE;
+DROP INDEX IF EXISTS idx_hosts_labels;
+ALTER TABLE hosts DROP COLUMN IF EXISTS labels;
@@ -0,0 +1,21 @@
+ALTER TABLE hosts
+ADD COLUMN labels TEXT [] NOT NULL DEFAULT '{}';
+CREATE INDEX idx_hosts_labels ON hosts USING GIN(labels);
+CREATE TABLE alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    metric_type VARCHAR(20) NOT NULL,
+    field VARCHAR(64) NOT NULL DEFAULT '',
+    match JSONB NOT NULL DEFAULT '{}',
+    operator VARCHAR(2) NOT NULL CHECK (operator IN ('>', '>=', '<', '<=', '==', '!=')),
+    threshold DOUBLE PRECISION NOT NULL,
+    for_seconds INTEGER NOT NULL DEFAULT 0 CHECK (for_seconds >= 0),
+    host_id UUID REFERENCES hosts(id) ON DELETE CASCADE,
+    host_label VARCHAR(63) NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL DEFAULT 'warning' CHECK (severity IN ('info', 'warning', 'critical')),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_alert_rules_enabled ON alert_rules(enabled);
@@ -0,0 +1,26 @@
+CREATE TABLE alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID REFERENCES alert_rules(id) ON DELETE SET NULL,
+    rule_name VARCHAR(255) NOT NULL,
+    host_id UUID NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    series VARCHAR(255) NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL,
+    state VARCHAR(20) NOT NULL CHECK (
+        state IN ('pending', 'firing', 'acknowledged', 'resolved')
+    ),
+    trigger_value DOUBLE PRECISION NOT NULL,
+    last_value DOUBLE PRECISION NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    fired_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by VARCHAR(255) NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolved_by VARCHAR(255) NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- По одному активному оповещению на правило, хост и ряд
+CREATE UNIQUE INDEX idx_alerts_active ON alerts(rule_id, host_id, series)
+WHERE state <> 'resolved';
+CREATE INDEX idx_alerts_host_started ON alerts(host_id, started_at DESC);
+CREATE INDEX idx_alerts_state ON alerts(state);
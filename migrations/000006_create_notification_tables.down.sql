@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notification_deliveries CASCADE;
+DROP TABLE IF EXISTS notification_channels CASCADE;
@@ -0,0 +1,24 @@
+CREATE TABLE notification_channels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('webhook', 'email', 'telegram')),
+    config JSONB NOT NULL DEFAULT '{}',
+    events TEXT [] NOT NULL DEFAULT '{}',
+    min_severity VARCHAR(20) NOT NULL DEFAULT 'info' CHECK (
+        min_severity IN ('info', 'warning', 'critical')
+    ),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    channel_id UUID NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    event VARCHAR(20) NOT NULL,
+    subject TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed')),
+    attempts INTEGER NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_notification_deliveries_channel ON notification_deliveries(channel_id, created_at DESC);
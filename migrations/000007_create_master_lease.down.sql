@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS master_history CASCADE;
+DROP TABLE IF EXISTS master_lease CASCADE;
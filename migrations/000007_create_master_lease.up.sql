@@ -0,0 +1,20 @@
+-- Единственная строка с текущим мастером. term растет при каждой смене
+-- мастера и служит fencing-токеном: изменение проходит только при
+-- совпадении ожидаемого term
+CREATE TABLE master_lease (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    host_id UUID REFERENCES hosts(id) ON DELETE SET NULL,
+    term BIGINT NOT NULL DEFAULT 0,
+    acquired_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
+INSERT INTO master_lease (id) VALUES (1);
+CREATE TABLE master_history (
+    id BIGSERIAL PRIMARY KEY,
+    term BIGINT NOT NULL,
+    old_host_id UUID REFERENCES hosts(id) ON DELETE SET NULL,
+    new_host_id UUID REFERENCES hosts(id) ON DELETE SET NULL,
+    reason VARCHAR(32) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_master_history_changed_at ON master_history(changed_at DESC);
@@ -0,0 +1,13 @@
+DELETE FROM master_history
+WHERE group_id <> '00000000-0000-0000-0000-000000000000';
+DROP INDEX IF EXISTS idx_master_history_group;
+ALTER TABLE master_history DROP COLUMN group_id;
+CREATE INDEX idx_master_history_changed_at ON master_history(changed_at DESC);
+DELETE FROM master_lease
+WHERE group_id <> '00000000-0000-0000-0000-000000000000';
+ALTER TABLE master_lease DROP CONSTRAINT master_lease_pkey;
+ALTER TABLE master_lease DROP COLUMN group_id;
+ALTER TABLE master_lease
+ADD COLUMN id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1);
+DROP TABLE IF EXISTS host_group_members CASCADE;
+DROP TABLE IF EXISTS host_groups CASCADE;
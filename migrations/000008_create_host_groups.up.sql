@@ -0,0 +1,29 @@
+CREATE TABLE host_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE host_group_members (
+    group_id UUID NOT NULL REFERENCES host_groups(id) ON DELETE CASCADE,
+    host_id UUID NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, host_id)
+);
+CREATE INDEX idx_host_group_members_host ON host_group_members(host_id);
+-- Аренда мастера теперь своя для каждой группы. Нулевой UUID - группа
+-- по умолчанию, в которой участвуют все хосты
+ALTER TABLE master_lease
+ADD COLUMN group_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE master_lease DROP COLUMN id;
+ALTER TABLE master_lease
+ADD PRIMARY KEY (group_id);
+ALTER TABLE master_lease
+ALTER COLUMN group_id DROP DEFAULT;
+ALTER TABLE master_history
+ADD COLUMN group_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE master_history
+ALTER COLUMN group_id DROP DEFAULT;
+DROP INDEX idx_master_history_changed_at;
+CREATE INDEX idx_master_history_group ON master_history(group_id, changed_at DESC);
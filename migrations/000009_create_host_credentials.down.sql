@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS host_inventory CASCADE;
+DROP TABLE IF EXISTS host_credentials CASCADE;
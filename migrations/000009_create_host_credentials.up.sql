@@ -0,0 +1,18 @@
+CREATE TABLE host_credentials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    host_id UUID NOT NULL REFERENCES hosts(id) ON DELETE CASCADE,
+    -- SHA-256 секрета в hex, сам секрет не хранится
+    secret_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX idx_host_credentials_host ON host_credentials(host_id);
+CREATE TABLE host_inventory (
+    host_id UUID PRIMARY KEY REFERENCES hosts(id) ON DELETE CASCADE,
+    hostname VARCHAR(255) NOT NULL,
+    ips TEXT [] NOT NULL DEFAULT '{}',
+    hardware JSONB NOT NULL DEFAULT '{}',
+    enrolled_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS api_tokens CASCADE;
+DROP TABLE IF EXISTS users CASCADE;
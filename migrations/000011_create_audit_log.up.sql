@@ -0,0 +1,34 @@
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Пользователь без внешнего ключа: запись переживает удаление пользователя
+    actor_id UUID,
+    actor_name VARCHAR(64) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id TEXT NOT NULL DEFAULT '',
+    -- Измененные поля: {"поле": {"before": ..., "after": ...}}
+    changes JSONB NOT NULL DEFAULT '{}',
+    status INTEGER NOT NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    source_ip VARCHAR(45) NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_audit_log_occurred_at ON audit_log(occurred_at DESC);
+CREATE INDEX idx_audit_log_target ON audit_log(target_type, target_id);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_name);
+
+-- Журнал только дополняется: изменение и удаление записей запрещены
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,147 @@
+// Команда ca - встроенный удостоверяющий центр для mTLS между агентами и ЦМ.
+//
+//	ca init   -dir ./pki                                   создать CA (ca.crt, ca.key)
+//	ca server -dir ./pki -dns monitoring-center,localhost   сертификат сервера ЦМ (server.crt, server.key)
+//	ca agent  -dir ./pki -host <host-id>                    сертификат агента хоста (agent-<host-id>.crt, .key)
+//
+// ca.crt передается ЦМ как TLS_CLIENT_CA_FILE и агентам как TLS_CA_FILE.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/pki"
+)
+
+const day = 24 * time.Hour
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "init":
+		err = initCA(os.Args[2:])
+	case "server":
+		err = issueServer(os.Args[2:])
+	case "agent":
+		err = issueAgent(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: ca <init|server|agent> [flags]")
+	os.Exit(2)
+}
+
+func initCA(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := flags.String("dir", "pki", "CA directory")
+	name := flags.String("cn", "Monitoring Center CA", "CA common name")
+	days := flags.Int("days", 3650, "Validity in days")
+	flags.Parse(args)
+
+	if _, err := pki.InitCA(*dir, *name, time.Duration(*days)*day); err != nil {
+		return err
+	}
+
+	log.Printf("CA created in %s", *dir)
+	return nil
+}
+
+func issueServer(args []string) error {
+	flags := flag.NewFlagSet("server", flag.ExitOnError)
+	dir := flags.String("dir", "pki", "CA directory")
+	dns := flags.String("dns", "monitoring-center,localhost", "DNS names, comma separated")
+	ips := flags.String("ip", "127.0.0.1", "IP addresses, comma separated")
+	days := flags.Int("days", 825, "Validity in days")
+	flags.Parse(args)
+
+	ca, err := pki.LoadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	req := pki.Request{
+		DNSNames: splitList(*dns),
+		Validity: time.Duration(*days) * day,
+	}
+	for _, value := range splitList(*ips) {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address %q", value)
+		}
+		req.IPs = append(req.IPs, ip)
+	}
+	if len(req.DNSNames) > 0 {
+		req.CommonName = req.DNSNames[0]
+	}
+
+	if _, err := ca.Issue("server", req); err != nil {
+		return err
+	}
+
+	log.Printf("Server certificate written to %s", ca.CertPath("server"))
+	return nil
+}
+
+func issueAgent(args []string) error {
+	flags := flag.NewFlagSet("agent", flag.ExitOnError)
+	dir := flags.String("dir", "pki", "CA directory")
+	host := flags.String("host", "", "Host ID (required)")
+	days := flags.Int("days", 365, "Validity in days")
+	flags.Parse(args)
+
+	hostID, err := uuid.Parse(*host)
+	if err != nil {
+		return fmt.Errorf("invalid host ID %q", *host)
+	}
+
+	ca, err := pki.LoadCA(*dir)
+	if err != nil {
+		return err
+	}
+
+	// ЦМ определяет хост по URI SAN, CN дублирует его для наглядности
+	name := "agent-" + hostID.String()
+	_, err = ca.Issue(name, pki.Request{
+		CommonName: hostID.String(),
+		URIs:       []*url.URL{pki.HostURI(hostID)},
+		Validity:   time.Duration(*days) * day,
+		Client:     true,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Agent certificate written to %s", ca.CertPath(name))
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -10,10 +10,19 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
 	"github.com/nekitmilk/monitoring-center/internal/config"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/pki"
+	"github.com/nekitmilk/monitoring-center/internal/service/alerting"
+	"github.com/nekitmilk/monitoring-center/internal/service/election"
+	"github.com/nekitmilk/monitoring-center/internal/service/ingest"
+	"github.com/nekitmilk/monitoring-center/internal/service/liveness"
+	"github.com/nekitmilk/monitoring-center/internal/service/notifier"
 	"github.com/nekitmilk/monitoring-center/internal/storage/mongo"
 	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
 	"github.com/nekitmilk/monitoring-center/internal/transport/http/handlers"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
 )
 
 func main() {
@@ -49,11 +58,65 @@ func main() {
 
 	// Инициализация репозитория
 	hostRepo := postgres.NewHostRepository(pgStorage.GetPool())
+	hostConfigRepo := postgres.NewHostConfigRepository(pgStorage.GetPool())
+	alertRuleRepo := postgres.NewAlertRuleRepository(pgStorage.GetPool())
+	alertRepo := postgres.NewAlertRepository(pgStorage.GetPool())
+	notificationRepo := postgres.NewNotificationRepository(pgStorage.GetPool())
+	masterRepo := postgres.NewMasterRepository(pgStorage.GetPool())
+	hostGroupRepo := postgres.NewHostGroupRepository(pgStorage.GetPool())
+	credentialRepo := postgres.NewCredentialRepository(pgStorage.GetPool())
+	userRepo := postgres.NewUserRepository(pgStorage.GetPool())
+	auditRepo := postgres.NewAuditRepository(pgStorage.GetPool())
 	metricRepo := mongo.NewMetricRepository(mongoStorage.GetClient(), "monitoring")
 
+	if err := bootstrapAdmin(ctx, userRepo, cfg); err != nil {
+		log.Fatalf("Failed to create admin user: %v", err)
+	}
+
+	// Фоновые сервисы работают до завершения приложения
+	appCtx, appCancel := context.WithCancel(context.Background())
+	defer appCancel()
+
+	livenessTracker := liveness.NewTracker(hostRepo, cfg.AgentPollingInterval, cfg.OfflineAfterMissed, cfg.LivenessCheckInterval)
+	go livenessTracker.Run(appCtx)
+
+	// Очередь приема метрик работает дольше остальных сервисов: при остановке
+	// она дописывается после HTTP-сервера, а не по отмене appCtx
+	ingestPipeline := ingest.NewPipeline(metricRepo, cfg.IngestQueueSize, cfg.IngestWorkers,
+		cfg.IngestBatchSize, cfg.IngestFlushInterval, cfg.IngestWriteTimeout)
+	ingestPipeline.Start()
+
+	// Метрики старше двух интервалов опроса агентов считаются устаревшими
+	alertEngine := alerting.NewEngine(alertRuleRepo, alertRepo, hostRepo, metricRepo, cfg.AlertEvalInterval, 2*cfg.AgentPollingInterval)
+	go alertEngine.Run(appCtx)
+
+	dispatcher := notifier.NewDispatcher(notificationRepo, hostRepo, hostGroupRepo, cfg.NotifyMaxAttempts, cfg.NotifyRetryBackoff, cfg.NotifyTimeout)
+	livenessTracker.OnStatusChange(dispatcher.HostStatusChanged)
+	alertEngine.OnTransition(dispatcher.AlertTransitioned)
+	go dispatcher.Run(appCtx)
+
+	elector := election.NewElector(hostRepo, hostGroupRepo, masterRepo, cfg.MasterCheckInterval, cfg.MasterLeaseTTL, cfg.MasterHoldDown)
+	livenessTracker.OnStatusChange(elector.HostStatusChanged)
+	elector.OnMasterChange(dispatcher.MasterChanged)
+	go elector.Run(appCtx)
+
 	// Инициализация обработчиков
-	hostHandler := handlers.NewHostHandler(hostRepo)
-	metricHandler := handlers.NewMetricHandler(metricRepo, hostRepo)
+	agentAuth := middleware.NewAgentAuth(credentialRepo, cfg.AllowAnonymousAgents, cfg.AgentKeyCacheTTL)
+	go agentAuth.Run(appCtx)
+
+	hostHandler := handlers.NewHostHandler(hostRepo, masterRepo, credentialRepo, agentAuth)
+	metricHandler := handlers.NewMetricHandler(metricRepo, agentAuth, livenessTracker, ingestPipeline, cfg.IngestRetryAfter)
+	hostConfigHandler := handlers.NewHostConfigHandler(hostConfigRepo, hostRepo)
+	alertRuleHandler := handlers.NewAlertRuleHandler(alertRuleRepo, hostRepo, alertEngine)
+	alertHandler := handlers.NewAlertHandler(alertRepo, alertEngine)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepo, dispatcher)
+	hostGroupHandler := handlers.NewHostGroupHandler(hostGroupRepo, hostRepo, masterRepo, elector)
+	agentHandler := handlers.NewAgentHandler(credentialRepo, cfg.BootstrapTokens)
+	credentialHandler := handlers.NewCredentialHandler(credentialRepo, hostRepo, agentAuth)
+	userHandler := handlers.NewUserHandler(userRepo)
+	loginLimiter := middleware.NewLoginLimiter(cfg.LoginMaxFailures, cfg.LoginMaxFailuresPerIP, cfg.LoginFailureWindow)
+	authHandler := handlers.NewAuthHandler(userRepo, loginLimiter, cfg.SessionTTL)
+	auditHandler := handlers.NewAuditHandler(auditRepo)
 
 	// Создание индексов MongoDB
 	indexCtx, indexCancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -66,25 +129,133 @@ func main() {
 	// Настройка роутинга
 	// router := gin.Default()
 	router := gin.New()
-	router.Use(gin.Logger(), gin.Recovery())
+	router.Use(gin.Logger(), gin.Recovery(), middleware.RequestID())
+
+	// Адрес клиента для журнала аудита берется из X-Forwarded-For только от доверенных прокси
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Роли пользователей: viewer читает, operator меняет хосты и работает
+	// с оповещениями, admin управляет пользователями, каналами и ключами агентов.
+	// Агенты аутентифицируются ключом агента или клиентским сертификатом.
+	authenticator := middleware.NewAuthenticator(userRepo, loginLimiter)
+	viewer := authenticator.RequireRole(models.UserRoleViewer)
+	operator := authenticator.RequireRole(models.UserRoleOperator)
+	admin := authenticator.RequireRole(models.UserRoleAdmin)
+	agent := agentAuth.RequireAgent()
 
-	api := router.Group("/api")
+	// Все изменяющие запросы попадают в журнал аудита, кроме приема метрик
+	// и пробной проверки правил, которые ничего не меняют
+	auditor := middleware.NewAuditor(auditRepo, "/api/metrics", "/api/alert-rules/evaluate", "/api/alert-rules/:id/evaluate")
+
+	api := router.Group("/api", auditor.Record())
 	{
+		authGroup := api.Group("/auth")
+		{
+			authGroup.POST("/login", authHandler.Login) // POST /api/auth/login
+			authGroup.GET("/me", viewer, authHandler.GetCurrentUser)
+			authGroup.GET("/tokens", viewer, authHandler.GetTokens)
+			authGroup.POST("/tokens", viewer, authHandler.CreateToken)
+			authGroup.DELETE("/tokens/:id", viewer, authHandler.RevokeToken)
+		}
+
+		users := api.Group("/users")
+		{
+			users.GET("", admin, userHandler.GetUsers)
+			users.POST("", admin, userHandler.CreateUser)
+			users.GET("/:id", admin, userHandler.GetUserByID)
+			users.PUT("/:id", admin, userHandler.UpdateUser)
+			users.DELETE("/:id", admin, userHandler.DeleteUser)
+		}
+
 		hosts := api.Group("/hosts")
 		{
-			hosts.GET("", hostHandler.GetHosts)             // GET /api/hosts
-			hosts.POST("", hostHandler.CreateHost)          // POST /api/hosts
-			hosts.GET("/:id", hostHandler.GetHostByID)      // GET /api/hosts/{id}
-			hosts.PUT("/:id", hostHandler.UpdateHost)       // PUT /api/hosts/{id}
-			hosts.DELETE("/:id", hostHandler.DeleteHost)    // DELETE /api/hosts/{id}
-			hosts.GET("/master", hostHandler.GetMasterHost) // GET /api/hosts/master
+			hosts.GET("", viewer, hostHandler.GetHosts)             // GET /api/hosts
+			hosts.POST("", operator, hostHandler.CreateHost)        // POST /api/hosts
+			hosts.GET("/:id", viewer, hostHandler.GetHostByID)      // GET /api/hosts/{id}
+			hosts.PUT("/:id", operator, hostHandler.UpdateHost)     // PUT /api/hosts/{id}
+			hosts.DELETE("/:id", operator, hostHandler.DeleteHost)  // DELETE /api/hosts/{id}
+			hosts.GET("/master", viewer, hostHandler.GetMasterHost) // GET /api/hosts/master
+			hosts.GET("/master/history", viewer, hostHandler.GetMasterHistory)
+			hosts.GET("/:id/status-history", viewer, hostHandler.GetHostStatusHistory)
+			hosts.GET("/:id/inventory", viewer, agentHandler.GetHostInventory)
+
+			// Ключи агента хоста
+			hosts.GET("/:id/credentials", operator, credentialHandler.GetCredentials)
+			hosts.POST("/:id/credentials/rotate", admin, credentialHandler.RotateCredential)
+			hosts.DELETE("/:id/credentials/:credential_id", admin, credentialHandler.RevokeCredential)
 
 			// Метрики хоста
-			hosts.GET("/:id/metrics", metricHandler.GetHostMetrics)
-			hosts.GET("/:id/metrics/latest", metricHandler.GetLatestHostMetrics)
+			hosts.GET("/:id/metrics", viewer, metricHandler.GetHostMetrics)
+			hosts.GET("/:id/metrics/latest", viewer, metricHandler.GetLatestHostMetrics)
+
+			// Конфигурация мониторинга хоста
+			hosts.GET("/:id/config", viewer, hostConfigHandler.GetHostConfig)
+			hosts.PUT("/:id/config", operator, hostConfigHandler.ReplaceHostConfig)
+			hosts.POST("/:id/config/processes", operator, hostConfigHandler.AddWatchedProcess)
+			hosts.DELETE("/:id/config/processes/:name", operator, hostConfigHandler.RemoveWatchedProcess)
+			hosts.POST("/:id/config/containers", operator, hostConfigHandler.AddWatchedContainer)
+			hosts.DELETE("/:id/config/containers/:name", operator, hostConfigHandler.RemoveWatchedContainer)
+			hosts.POST("/:id/config/ports", operator, hostConfigHandler.AddWatchedPort)
+			hosts.DELETE("/:id/config/ports/:protocol/:port", operator, hostConfigHandler.RemoveWatchedPort)
+		}
+
+		groups := api.Group("/groups")
+		{
+			groups.GET("", viewer, hostGroupHandler.GetGroups)
+			groups.POST("", operator, hostGroupHandler.CreateGroup)
+			groups.GET("/:id", viewer, hostGroupHandler.GetGroupByID)
+			groups.PUT("/:id", operator, hostGroupHandler.UpdateGroup)
+			groups.DELETE("/:id", operator, hostGroupHandler.DeleteGroup)
+			groups.GET("/:id/members", viewer, hostGroupHandler.GetGroupMembers)
+			groups.PUT("/:id/members/:host_id", operator, hostGroupHandler.AddGroupMember)
+			groups.DELETE("/:id/members/:host_id", operator, hostGroupHandler.RemoveGroupMember)
+			groups.GET("/:id/master", viewer, hostGroupHandler.GetGroupMaster) // GET /api/groups/{id}/master
+			groups.GET("/:id/master/history", viewer, hostGroupHandler.GetGroupMasterHistory)
 		}
 
-		// Эндпоинт для приема метрик от агентов
+		alertRules := api.Group("/alert-rules")
+		{
+			alertRules.GET("", viewer, alertRuleHandler.GetAlertRules)
+			alertRules.POST("", operator, alertRuleHandler.CreateAlertRule)
+			alertRules.POST("/evaluate", viewer, alertRuleHandler.EvaluateAlertRule) // Пробная проверка без сохранения
+			alertRules.GET("/:id", viewer, alertRuleHandler.GetAlertRuleByID)
+			alertRules.PUT("/:id", operator, alertRuleHandler.UpdateAlertRule)
+			alertRules.DELETE("/:id", operator, alertRuleHandler.DeleteAlertRule)
+			alertRules.POST("/:id/evaluate", viewer, alertRuleHandler.EvaluateStoredAlertRule)
+		}
+
+		alerts := api.Group("/alerts")
+		{
+			alerts.GET("", viewer, alertHandler.GetAlerts)                   // GET /api/alerts
+			alerts.GET("/:id", viewer, alertHandler.GetAlertByID)            // GET /api/alerts/{id}
+			alerts.POST("/:id/ack", operator, alertHandler.AcknowledgeAlert) // POST /api/alerts/{id}/ack
+			alerts.POST("/:id/resolve", operator, alertHandler.ResolveAlert) // POST /api/alerts/{id}/resolve
+		}
+
+		channels := api.Group("/notification-channels")
+		{
+			channels.GET("", admin, notificationHandler.GetChannels)
+			channels.POST("", admin, notificationHandler.CreateChannel)
+			channels.GET("/:id", admin, notificationHandler.GetChannelByID)
+			channels.PUT("/:id", admin, notificationHandler.UpdateChannel)
+			channels.DELETE("/:id", admin, notificationHandler.DeleteChannel)
+			channels.POST("/:id/test", admin, notificationHandler.TestChannel)        // Тестовое уведомление
+			channels.GET("/:id/deliveries", admin, notificationHandler.GetDeliveries) // Журнал доставки
+		}
+
+		api.GET("/audit", admin, auditHandler.GetAuditLog) // GET /api/audit
+
+		// Эндпоинты, которые опрашивают агенты
+		agents := api.Group("/agents")
+		{
+			agents.POST("/enroll", agentHandler.Enroll)                        // POST /api/agents/enroll, по bootstrap-токену
+			agents.GET("/:id/config", agent, hostConfigHandler.GetAgentConfig) // GET /api/agents/{id}/config
+			agents.GET("/:id/role", agent, hostGroupHandler.GetAgentRole)      // GET /api/agents/{id}/role
+		}
+
+		// Эндпоинт для приема метрик от агентов, агент проверяется по host_id из пакета
 		api.POST("/metrics", metricHandler.ReceiveMetrics)
 	}
 
@@ -94,10 +265,25 @@ func main() {
 		Handler: router,
 	}
 
+	if cfg.TLSCertFile != "" {
+		tlsConfig, err := pki.ServerTLSConfig(cfg.TLSClientCAFile, cfg.TLSClientAuth)
+		if err != nil {
+			log.Fatalf("Invalid TLS configuration: %v", err)
+		}
+		server.TLSConfig = tlsConfig
+	}
+
 	// Запуск сервера в горутине
 	go func() {
-		log.Printf("Starting server on %s", cfg.ServerAddress)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLSCertFile != "" {
+			log.Printf("Starting HTTPS server on %s (client auth: %s)", cfg.ServerAddress, server.TLSConfig.ClientAuth)
+			err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			log.Printf("Starting server on %s", cfg.ServerAddress)
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
@@ -107,14 +293,58 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	appCancel()
 
 	// Graceful shutdown
 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// Новые пакеты уже не принимаются, дописываем принятые
+	log.Printf("Draining ingest queue (%d batches)...", ingestPipeline.Len())
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.IngestDrainTimeout)
+	defer drainCancel()
+
+	if err := ingestPipeline.Shutdown(drainCtx); err != nil {
+		log.Printf("Warning: %v", err)
 	}
 
 	log.Println("Server exited")
 }
+
+// bootstrapAdmin создает администратора из ADMIN_USERNAME и ADMIN_PASSWORD,
+// пока в БД нет ни одного пользователя
+func bootstrapAdmin(ctx context.Context, userRepo *postgres.UserRepository, cfg config.Config) error {
+	if cfg.AdminPassword == "" {
+		count, err := userRepo.Count(ctx)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			log.Println("Warning: no users exist, set ADMIN_PASSWORD to create the first admin")
+		}
+		return nil
+	}
+
+	passwordHash, err := auth.HashPassword(cfg.AdminPassword)
+	if err != nil {
+		return err
+	}
+
+	created, err := userRepo.CreateFirst(ctx, &models.User{
+		Username:     cfg.AdminUsername,
+		PasswordHash: passwordHash,
+		Role:         models.UserRoleAdmin,
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("Created admin user %q", cfg.AdminUsername)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword возвращает bcrypt-хеш пароля для хранения в БД
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword сравнивает пароль с bcrypt-хешем
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Хеш DefaultCost, с которым сравнивается пароль неизвестного или отключенного
+// пользователя: ответ занимает столько же времени, сколько и для существующего
+const dummyHash = "$2a$10$vHtBjJP1yawzNccccfGMFu/Nkaotg6cs6TH7rosxcA0cZEyNFM7we"
+
+// VerifyUser сообщает, что пользователь существует, включен и пароль верен.
+// bcrypt выполняется всегда, чтобы по времени ответа нельзя было подобрать имена.
+func VerifyUser(user *models.User, password string) bool {
+	if user == nil || user.Disabled {
+		CheckPassword(dummyHash, password)
+		return false
+	}
+	return CheckPassword(user.PasswordHash, password)
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewSecret создает случайный секрет и его хеш для хранения в БД.
+// Секрет показывается клиенту один раз.
+func NewSecret() (secret, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	secret = hex.EncodeToString(raw)
+	return secret, HashSecret(secret), nil
+}
+
+// HashSecret возвращает SHA-256 секрета в hex. Секреты случайные и длинные,
+// поэтому медленный хеш с солью для них не нужен.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenAllowed сравнивает токен со списком разрешенных за постоянное время
+func TokenAllowed(token string, allowed []string) bool {
+	ok := false
+	for _, candidate := range allowed {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+			ok = true
+		}
+	}
+	return ok && token != ""
+}
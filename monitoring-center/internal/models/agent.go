@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Заголовок, в котором агент передает секрет своего ключа
+const AgentKeyHeader = "X-Agent-Key"
+
+// HostCredential ключ, которым агент хоста подписывает запросы к ЦМ.
+// Хранится только SHA-256 секрета.
+type HostCredential struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	HostID     uuid.UUID  `json:"host_id" db:"host_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// Hardware сводка об оборудовании и ОС, которую агент передает при регистрации
+type Hardware struct {
+	OS              string `json:"os"`
+	Platform        string `json:"platform"`
+	PlatformVersion string `json:"platform_version"`
+	KernelVersion   string `json:"kernel_version"`
+	Arch            string `json:"arch"`
+	CPUModel        string `json:"cpu_model"`
+	CPUCores        int    `json:"cpu_cores"`
+	MemoryTotal     uint64 `json:"memory_total"`
+}
+
+// HostInventory сведения о машине хоста на момент последней регистрации агента
+type HostInventory struct {
+	HostID     uuid.UUID `json:"host_id" db:"host_id"`
+	Hostname   string    `json:"hostname" db:"hostname"`
+	IPs        []string  `json:"ips" db:"ips"`
+	Hardware   Hardware  `json:"hardware" db:"hardware"`
+	EnrolledAt time.Time `json:"enrolled_at" db:"enrolled_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EnrollRequest запрос агента на регистрацию хоста по bootstrap-токену
+type EnrollRequest struct {
+	Token    string   `json:"token" binding:"required"`
+	Hostname string   `json:"hostname" binding:"required,min=1,max=255"`
+	IPs      []string `json:"ips" binding:"required,min=1,dive,ip"` // Первый адрес становится IP хоста
+	Hardware Hardware `json:"hardware"`
+	// Приоритет и метки создаваемого хоста, по умолчанию - 1 и без меток
+	Priority int      `json:"priority" binding:"omitempty,min=1,max=100"`
+	Labels   []string `json:"labels" binding:"omitempty,max=20,dive,min=1,max=63"`
+}
+
+// EnrollResponse идентификатор хоста и ключ агента. Секрет возвращается
+// только один раз, агент сохраняет его у себя.
+type EnrollResponse struct {
+	HostID       uuid.UUID `json:"host_id"`
+	CredentialID uuid.UUID `json:"credential_id"`
+	Secret       string    `json:"secret"`
+}
+
+// IssuedCredential новый ключ агента. Секрет возвращается только один раз.
+type IssuedCredential struct {
+	ID     uuid.UUID `json:"id"`
+	Secret string    `json:"secret"`
+}
+
+// RotateCredentialResponse новый ключ и число отозванных прежних ключей
+type RotateCredentialResponse struct {
+	Credential IssuedCredential `json:"credential"`
+	Revoked    int64            `json:"revoked"`
+}
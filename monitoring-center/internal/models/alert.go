@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AlertState string
+
+const (
+	AlertPending      AlertState = "pending"
+	AlertFiring       AlertState = "firing"
+	AlertAcknowledged AlertState = "acknowledged"
+	AlertResolved     AlertState = "resolved"
+)
+
+// Alert экземпляр срабатывания правила на конкретном хосте:
+// pending -> firing -> acknowledged -> resolved
+type Alert struct {
+	ID       uuid.UUID     `json:"id" db:"id"`
+	RuleID   *uuid.UUID    `json:"rule_id" db:"rule_id"` // nil, если правило удалено
+	RuleName string        `json:"rule_name" db:"rule_name"`
+	HostID   uuid.UUID     `json:"host_id" db:"host_id"`
+	Series   string        `json:"series" db:"series"`
+	Severity AlertSeverity `json:"severity" db:"severity"`
+	State    AlertState    `json:"state" db:"state"`
+	// Значение, при котором оповещение сработало, и последнее наблюдаемое значение
+	TriggerValue   float64    `json:"trigger_value" db:"trigger_value"`
+	LastValue      float64    `json:"last_value" db:"last_value"`
+	StartedAt      time.Time  `json:"started_at" db:"started_at"`
+	FiredAt        *time.Time `json:"fired_at" db:"fired_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at" db:"acknowledged_at"`
+	AcknowledgedBy string     `json:"acknowledged_by" db:"acknowledged_by"`
+	ResolvedAt     *time.Time `json:"resolved_at" db:"resolved_at"` // Время окончания
+	ResolvedBy     string     `json:"resolved_by" db:"resolved_by"` // Пусто - разрешено автоматически
+	Comment        string     `json:"comment" db:"comment"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// AlertTransition переход оповещения между состояниями
+type AlertTransition struct {
+	Alert Alert      `json:"alert"`
+	From  AlertState `json:"from,omitempty"` // Пусто для только что созданного оповещения
+	To    AlertState `json:"to"`
+}
+
+// AlertsQuery параметры запроса для получения оповещений
+type AlertsQuery struct {
+	Page   int        `form:"page" json:"page" binding:"omitempty,min=1"`
+	Limit  int        `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`
+	HostID string     `form:"host_id" json:"host_id" binding:"omitempty,uuid"`
+	RuleID string     `form:"rule_id" json:"rule_id" binding:"omitempty,uuid"`
+	State  AlertState `form:"state" json:"state" binding:"omitempty,oneof=pending firing acknowledged resolved"`
+	// Оповещения, активные хотя бы частично в промежутке [From, To]
+	From *time.Time `form:"from" json:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" json:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// AlertsResponse ответ с пагинацией
+type AlertsResponse struct {
+	Alerts      []Alert `json:"alerts"`
+	Total       int     `json:"total"`
+	Page        int     `json:"page"`
+	Limit       int     `json:"limit"`
+	TotalPages  int     `json:"total_pages"`
+	HasNext     bool    `json:"has_next"`
+	HasPrevious bool    `json:"has_previous"`
+}
+
+// AlertActionRequest параметры подтверждения или ручного разрешения оповещения.
+// Исполнитель берется из аутентифицированного пользователя.
+type AlertActionRequest struct {
+	Comment string `json:"comment" binding:"max=1000"`
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AlertSeverity string
+
+const (
+	SeverityInfo     AlertSeverity = "info"
+	SeverityWarning  AlertSeverity = "warning"
+	SeverityCritical AlertSeverity = "critical"
+)
+
+// Duration длительность, которая в JSON записывается строкой вида "5m"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON принимает строку вида "5m" или число секунд
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		*d = Duration(time.Duration(v * float64(time.Second)))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+
+	return nil
+}
+
+// AlertRule пороговое правило: "cpu > 90 в течение 5m на хостах с меткой db"
+// или "disk /var usage_percent > 85"
+type AlertRule struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	MetricType  MetricType `json:"metric_type" db:"metric_type"`
+	// Поле детальных данных метрики (например usage_percent). Пустое - Metric.Value
+	Field string `json:"field" db:"field"`
+	// Фильтр по полям детальных данных, например {"mount_point": "/var"}
+	Match     map[string]string `json:"match" db:"match"`
+	Operator  string            `json:"operator" db:"operator"`
+	Threshold float64           `json:"threshold" db:"threshold"`
+	// Сколько условие должно выполняться непрерывно, прежде чем сработать
+	For Duration `json:"for" db:"for_seconds"`
+	// Область действия: конкретный хост, хосты с меткой или все хосты
+	HostID    *uuid.UUID    `json:"host_id" db:"host_id"`
+	HostLabel string        `json:"host_label" db:"host_label"`
+	Severity  AlertSeverity `json:"severity" db:"severity"`
+	Enabled   bool          `json:"enabled" db:"enabled"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// AlertRuleRequest параметры запроса для создания, изменения и пробной проверки правила
+type AlertRuleRequest struct {
+	Name        string            `json:"name" binding:"required,min=1,max=255"`
+	Description string            `json:"description" binding:"max=1000"`
+	MetricType  MetricType        `json:"metric_type" binding:"required,oneof=cpu ram disk disk_io process port container agent network pressure"`
+	Field       string            `json:"field" binding:"max=64"`
+	Match       map[string]string `json:"match" binding:"max=10"`
+	Operator    string            `json:"operator" binding:"required,oneof=> >= < <= == !="`
+	Threshold   *float64          `json:"threshold" binding:"required"`
+	For         Duration          `json:"for"`
+	HostID      *uuid.UUID        `json:"host_id"`
+	HostLabel   string            `json:"host_label" binding:"max=63"`
+	Severity    AlertSeverity     `json:"severity" binding:"omitempty,oneof=info warning critical"`
+	Enabled     *bool             `json:"enabled"`
+}
+
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Validate проверяет то, что не выражается тегами binding
+func (r *AlertRuleRequest) Validate() error {
+	if r.Field != "" && !fieldNamePattern.MatchString(r.Field) {
+		return fmt.Errorf("field must be a snake_case data field name")
+	}
+	for key := range r.Match {
+		if !fieldNamePattern.MatchString(key) {
+			return fmt.Errorf("match key %q must be a snake_case data field name", key)
+		}
+	}
+	if r.For < 0 || time.Duration(r.For) > 24*time.Hour {
+		return fmt.Errorf("for must be between 0 and 24h")
+	}
+	if time.Duration(r.For)%time.Second != 0 {
+		return fmt.Errorf("for must be a whole number of seconds")
+	}
+	if r.HostID != nil && r.HostLabel != "" {
+		return fmt.Errorf("host_id and host_label are mutually exclusive")
+	}
+	return nil
+}
+
+// ToRule переносит параметры запроса в правило, подставляя значения по умолчанию
+func (r *AlertRuleRequest) ToRule(rule *AlertRule) {
+	rule.Name = r.Name
+	rule.Description = r.Description
+	rule.MetricType = r.MetricType
+	rule.Field = r.Field
+	rule.Match = r.Match
+	if rule.Match == nil {
+		rule.Match = map[string]string{}
+	}
+	rule.Operator = r.Operator
+	rule.Threshold = *r.Threshold
+	rule.For = r.For
+	rule.HostID = r.HostID
+	rule.HostLabel = r.HostLabel
+	rule.Severity = r.Severity
+	if rule.Severity == "" {
+		rule.Severity = SeverityWarning
+	}
+	rule.Enabled = true
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+}
+
+// RuleEvaluation результат проверки правила для одного ряда метрик хоста
+type RuleEvaluation struct {
+	HostID   uuid.UUID `json:"host_id"`
+	HostName string    `json:"host_name"`
+	// Ряд внутри хоста: точка монтирования, имя процесса и т.п.
+	Series    string     `json:"series,omitempty"`
+	Value     *float64   `json:"value"` // Последнее значение, nil - нет свежих данных
+	Breaching bool       `json:"breaching"`
+	Since     *time.Time `json:"since,omitempty"` // Начало непрерывного нарушения порога
+	Firing    bool       `json:"firing"`
+	Samples   int        `json:"samples"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Имя исполнителя запросов без аутентифицированного пользователя (агенты, вход)
+const AnonymousActor = "anonymous"
+
+// AuditEntry запись журнала аудита об изменяющем запросе к API
+type AuditEntry struct {
+	ID         int64      `json:"id" db:"id"`
+	OccurredAt time.Time  `json:"occurred_at" db:"occurred_at"`
+	ActorID    *uuid.UUID `json:"actor_id" db:"actor_id"`
+	ActorName  string     `json:"actor_name" db:"actor_name"`
+	// Имя обработчика, например UpdateHost
+	Action     string `json:"action" db:"action"`
+	Method     string `json:"method" db:"method"`
+	Path       string `json:"path" db:"path"`
+	TargetType string `json:"target_type" db:"target_type"`
+	TargetID   string `json:"target_id" db:"target_id"`
+	// Измененные поля объекта: {"поле": {"before": ..., "after": ...}}
+	Changes   map[string]FieldChange `json:"changes" db:"changes"`
+	Status    int                    `json:"status" db:"status"`
+	RequestID string                 `json:"request_id" db:"request_id"`
+	SourceIP  string                 `json:"source_ip" db:"source_ip"`
+}
+
+// FieldChange значение поля до и после изменения. null - поля не было.
+type FieldChange struct {
+	Before json.RawMessage `json:"before" swaggertype:"object"`
+	After  json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+// AuditQuery параметры запроса журнала аудита
+type AuditQuery struct {
+	Page       int    `form:"page" json:"page" binding:"omitempty,min=1"`
+	Limit      int    `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`
+	Actor      string `form:"actor" json:"actor"`
+	Action     string `form:"action" json:"action"`
+	Method     string `form:"method" json:"method" binding:"omitempty,oneof=POST PUT PATCH DELETE"`
+	TargetType string `form:"target_type" json:"target_type"`
+	TargetID   string `form:"target_id" json:"target_id"`
+	RequestID  string `form:"request_id" json:"request_id"`
+	// Записи в промежутке [From, To]
+	From *time.Time `form:"from" json:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" json:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// AuditResponse ответ с пагинацией
+type AuditResponse struct {
+	Entries     []AuditEntry `json:"entries"`
+	Total       int          `json:"total"`
+	Page        int          `json:"page"`
+	Limit       int          `json:"limit"`
+	TotalPages  int          `json:"total_pages"`
+	HasNext     bool         `json:"has_next"`
+	HasPrevious bool         `json:"has_previous"`
+}
@@ -16,13 +16,25 @@ const (
 )
 
 type Host struct {
-	ID        uuid.UUID  `json:"id" db:"id"`
-	Name      string     `json:"name" db:"name"`
-	IP        string     `json:"ip" db:"ip"`
-	Priority  int        `json:"priority" db:"priority"`
-	Status    HostStatus `json:"status" db:"status"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	ID       uuid.UUID  `json:"id" db:"id"`
+	Name     string     `json:"name" db:"name"`
+	IP       string     `json:"ip" db:"ip"`
+	Priority int        `json:"priority" db:"priority"`
+	Status   HostStatus `json:"status" db:"status"`
+	Labels   []string   `json:"labels" db:"labels"`
+	// Время последнего приема метрик от агента хоста
+	LastSeenAt      *time.Time `json:"last_seen_at" db:"last_seen_at"`
+	StatusChangedAt *time.Time `json:"status_changed_at" db:"status_changed_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// HostStatusChange переход хоста из одного статуса в другой
+type HostStatusChange struct {
+	HostID    uuid.UUID  `json:"host_id" db:"host_id"`
+	OldStatus HostStatus `json:"old_status" db:"old_status"`
+	NewStatus HostStatus `json:"new_status" db:"new_status"`
+	ChangedAt time.Time  `json:"changed_at" db:"changed_at"`
 }
 
 // CreateHostRequest параметры запроса для создания нового хоста
@@ -30,6 +42,14 @@ type CreateHostRequest struct {
 	Name     string `json:"name" binding:"required,min=1,max=255"`
 	IP       string `json:"ip" binding:"required,ip"`
 	Priority int    `json:"priority" binding:"required,min=1,max=100"`
+	// Метки для выбора хостов в правилах оповещений, например "db"
+	Labels []string `json:"labels" binding:"omitempty,max=20,dive,min=1,max=63"`
+}
+
+// CreateHostResponse созданный хост и ключ для его агента
+type CreateHostResponse struct {
+	Host
+	Credential IssuedCredential `json:"credential"`
 }
 
 // HostsQuery параметры запроса для получения хостов
@@ -39,6 +59,7 @@ type HostsQuery struct {
 	Status   HostStatus `form:"status" json:"status"`
 	Priority int        `form:"priority" json:"priority" binding:"omitempty,min=1,max=100"`
 	Search   string     `form:"search" json:"search"`
+	Label    string     `form:"label" json:"label"`
 }
 
 // HostsResponse ответ с пагинацией
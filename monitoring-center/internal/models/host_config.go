@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HostConfig конфигурация мониторинга хоста, которую забирает агент
+type HostConfig struct {
+	HostID     uuid.UUID     `json:"host_id"`
+	Version    int64         `json:"version"` // Увеличивается при каждом изменении, 0 - конфигурация не задавалась
+	Processes  []string      `json:"processes"`
+	Containers []string      `json:"containers"`
+	Ports      []WatchedPort `json:"ports"`
+	UpdatedAt  *time.Time    `json:"updated_at,omitempty"`
+}
+
+// WatchedPort порт, открытие которого ожидается на хосте
+type WatchedPort struct {
+	Port     int    `json:"port" binding:"required,min=1,max=65535"`
+	Protocol string `json:"protocol" binding:"required,oneof=tcp udp"`
+}
+
+// UpdateHostConfigRequest полностью заменяет конфигурацию хоста
+type UpdateHostConfigRequest struct {
+	Processes  []string      `json:"processes" binding:"dive,required,max=255"`
+	Containers []string      `json:"containers" binding:"dive,required,max=255"`
+	Ports      []WatchedPort `json:"ports" binding:"dive"`
+}
+
+// WatchedNameRequest добавляет процесс или контейнер в список наблюдения
+type WatchedNameRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=255"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HostGroup группа хостов (например кластер БД) со своим мастером
+type HostGroup struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HostGroupRequest параметры запроса для создания и изменения группы
+type HostGroupRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=255"`
+	Description string `json:"description" binding:"max=1000"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultGroupID группа по умолчанию: в ее выборах участвуют все хосты
+var DefaultGroupID = uuid.Nil
+
+// MasterLease текущий мастер группы. Term увеличивается при каждой смене мастера
+// и служит fencing-токеном: команда со старым term должна отвергаться.
+type MasterLease struct {
+	GroupID    uuid.UUID  `json:"group_id" db:"group_id"`
+	HostID     *uuid.UUID `json:"host_id" db:"host_id"` // nil - нет ни одного online хоста
+	Term       int64      `json:"term" db:"term"`
+	AcquiredAt *time.Time `json:"acquired_at" db:"acquired_at"`
+	// Продлевается, пока центр подтверждает мастера
+	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// ActiveMaster возвращает мастера аренды или nil, если мастера нет или аренда
+// истекла: центр перестал ее продлевать, и мастер больше не подтвержден
+func (l *MasterLease) ActiveMaster(now time.Time) *uuid.UUID {
+	if l.HostID == nil || (l.ExpiresAt != nil && now.After(*l.ExpiresAt)) {
+		return nil
+	}
+	return l.HostID
+}
+
+// Причины смены мастера
+const (
+	MasterReasonElected   = "elected"         // Мастера не было
+	MasterReasonOffline   = "master_offline"  // Мастер перестал быть online
+	MasterReasonRemoved   = "master_removed"  // Мастер удален или исключен из группы
+	MasterReasonPreempted = "higher_priority" // Хост с большим приоритетом стабилен дольше hold-down
+	MasterReasonExpired   = "lease_expired"   // Аренда не продлевалась дольше TTL
+)
+
+// MasterChange запись истории смены мастера
+type MasterChange struct {
+	GroupID   uuid.UUID  `json:"group_id" db:"group_id"`
+	Term      int64      `json:"term" db:"term"`
+	OldHostID *uuid.UUID `json:"old_host_id" db:"old_host_id"`
+	NewHostID *uuid.UUID `json:"new_host_id" db:"new_host_id"`
+	Reason    string     `json:"reason" db:"reason"`
+	ChangedAt time.Time  `json:"changed_at" db:"changed_at"`
+}
+
+type Role string
+
+const (
+	RoleMaster Role = "master"
+	RoleBackup Role = "backup"
+)
+
+// AgentRole роль хоста в группе, которую опрашивает агент
+type AgentRole struct {
+	HostID    uuid.UUID `json:"host_id"`
+	GroupID   uuid.UUID `json:"group_id"`
+	GroupName string    `json:"group_name"`
+	Role      Role      `json:"role"`
+	// Term аренды: скрипты на хосте могут отвергать команды со старым term
+	Term           int64      `json:"term"`
+	MasterHostID   *uuid.UUID `json:"master_host_id"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+}
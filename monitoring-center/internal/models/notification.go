@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChannelType string
+
+const (
+	ChannelWebhook  ChannelType = "webhook"
+	ChannelEmail    ChannelType = "email"
+	ChannelTelegram ChannelType = "telegram"
+)
+
+// NotificationEvent вид события, на которое можно подписать канал
+type NotificationEvent string
+
+const (
+	EventHostStatus NotificationEvent = "host_status"
+	EventAlert      NotificationEvent = "alert"
+	EventMaster     NotificationEvent = "master"
+	EventTest       NotificationEvent = "test"
+)
+
+// NotificationChannel канал доставки уведомлений. Config зависит от типа:
+// webhook - {"url", "headers"}, email - {"host", "port", "username", "password", "from", "to"},
+// telegram - {"base_url", "token", "chat_id"}
+type NotificationChannel struct {
+	ID     uuid.UUID       `json:"id" db:"id"`
+	Name   string          `json:"name" db:"name"`
+	Type   ChannelType     `json:"type" db:"type"`
+	Config json.RawMessage `json:"config" db:"config" swaggertype:"object"`
+	// События, на которые подписан канал. Пустой список - все события
+	Events []NotificationEvent `json:"events" db:"events"`
+	// Оповещения ниже этой важности в канал не отправляются
+	MinSeverity AlertSeverity `json:"min_severity" db:"min_severity"`
+	Enabled     bool          `json:"enabled" db:"enabled"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribed сообщает, нужно ли отправлять в канал событие заданной важности
+func (c *NotificationChannel) Subscribed(event NotificationEvent, severity AlertSeverity) bool {
+	if !c.Enabled {
+		return false
+	}
+	if severity != "" && severityRank[severity] < severityRank[c.MinSeverity] {
+		return false
+	}
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+var severityRank = map[AlertSeverity]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// NotificationChannelRequest параметры запроса для создания и изменения канала
+type NotificationChannelRequest struct {
+	Name        string              `json:"name" binding:"required,min=1,max=255"`
+	Type        ChannelType         `json:"type" binding:"required,oneof=webhook email telegram"`
+	Config      json.RawMessage     `json:"config" binding:"required" swaggertype:"object"`
+	Events      []NotificationEvent `json:"events" binding:"dive,oneof=host_status alert master"`
+	MinSeverity AlertSeverity       `json:"min_severity" binding:"omitempty,oneof=info warning critical"`
+	Enabled     *bool               `json:"enabled"`
+}
+
+// ToChannel переносит параметры запроса в канал, подставляя значения по умолчанию
+func (r *NotificationChannelRequest) ToChannel(channel *NotificationChannel) {
+	channel.Name = r.Name
+	channel.Type = r.Type
+	channel.Config = r.Config
+	channel.Events = r.Events
+	if channel.Events == nil {
+		channel.Events = []NotificationEvent{}
+	}
+	channel.MinSeverity = r.MinSeverity
+	if channel.MinSeverity == "" {
+		channel.MinSeverity = SeverityInfo
+	}
+	channel.Enabled = true
+	if r.Enabled != nil {
+		channel.Enabled = *r.Enabled
+	}
+}
+
+type DeliveryStatus string
+
+const (
+	DeliverySent   DeliveryStatus = "sent"
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// NotificationDelivery запись журнала доставки уведомления в канал
+type NotificationDelivery struct {
+	ID        int64             `json:"id" db:"id"`
+	ChannelID uuid.UUID         `json:"channel_id" db:"channel_id"`
+	Event     NotificationEvent `json:"event" db:"event"`
+	Subject   string            `json:"subject" db:"subject"`
+	Status    DeliveryStatus    `json:"status" db:"status"`
+	Attempts  int               `json:"attempts" db:"attempts"`
+	Error     string            `json:"error" db:"error"` // Ошибка последней попытки
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserRole роль пользователя API. Каждая следующая роль включает права предыдущей:
+// viewer - чтение хостов и метрик, operator - изменение хостов и работа
+// с оповещениями, admin - управление пользователями и каналами уведомлений.
+type UserRole string
+
+const (
+	UserRoleViewer   UserRole = "viewer"
+	UserRoleOperator UserRole = "operator"
+	UserRoleAdmin    UserRole = "admin"
+)
+
+var userRoleRank = map[UserRole]int{
+	UserRoleViewer:   1,
+	UserRoleOperator: 2,
+	UserRoleAdmin:    3,
+}
+
+// Allows сообщает, достаточно ли роли для действия, требующего роль required
+func (r UserRole) Allows(required UserRole) bool {
+	rank, ok := userRoleRank[r]
+	return ok && rank >= userRoleRank[required]
+}
+
+type User struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         UserRole  `json:"role" db:"role"`
+	Disabled     bool      `json:"disabled" db:"disabled"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// APIToken токен доступа пользователя к API. Хранится только SHA-256 токена.
+type APIToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// CreateUserRequest параметры запроса для создания пользователя
+type CreateUserRequest struct {
+	Username string   `json:"username" binding:"required,min=3,max=64,excludesall=:"`
+	Password string   `json:"password" binding:"required,min=8,max=72"` // bcrypt учитывает только 72 байта
+	Role     UserRole `json:"role" binding:"required,oneof=viewer operator admin"`
+}
+
+// UpdateUserRequest параметры запроса для изменения пользователя. Пустые поля не меняются.
+type UpdateUserRequest struct {
+	Password string   `json:"password" binding:"omitempty,min=8,max=72"`
+	Role     UserRole `json:"role" binding:"omitempty,oneof=viewer operator admin"`
+	Disabled *bool    `json:"disabled"`
+}
+
+// LoginRequest вход по имени и паролю
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// CreateTokenRequest параметры нового токена. Без срока действия токен бессрочный.
+type CreateTokenRequest struct {
+	Name          string `json:"name" binding:"required,min=1,max=255"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// IssuedToken новый токен. Сам токен возвращается только один раз.
+type IssuedToken struct {
+	APIToken
+	Token string `json:"token"`
+}
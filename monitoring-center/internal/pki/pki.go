@@ -0,0 +1,107 @@
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Режимы проверки клиентских сертификатов
+const (
+	ClientAuthNone     = "none"     // Сертификаты не запрашиваются
+	ClientAuthOptional = "optional" // Проверяются, если клиент их предъявил
+	ClientAuthRequire  = "require"  // Без сертификата соединение не устанавливается
+)
+
+// ServerTLSConfig настройки TLS сервера ЦМ. Клиентские сертификаты
+// проверяются по сертификатам из clientCAFile. Пустой режим - optional,
+// если clientCAFile задан, иначе none.
+func ServerTLSConfig(clientCAFile, clientAuth string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if clientAuth == "" {
+		clientAuth = ClientAuthNone
+		if clientCAFile != "" {
+			clientAuth = ClientAuthOptional
+		}
+	}
+
+	switch clientAuth {
+	case ClientAuthNone:
+		return config, nil
+	case ClientAuthOptional:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client auth mode %q", clientAuth)
+	}
+
+	if clientCAFile == "" {
+		return nil, fmt.Errorf("client CA file is required for client auth mode %q", clientAuth)
+	}
+	pool, err := LoadCertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	config.ClientCAs = pool
+
+	return config, nil
+}
+
+// LoadCertPool читает сертификаты в формате PEM
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// HostURI SAN сертификата агента, по которому ЦМ определяет хост
+func HostURI(hostID uuid.UUID) *url.URL {
+	return &url.URL{Scheme: "urn", Opaque: "uuid:" + hostID.String()}
+}
+
+// HostIDFromCert возвращает ID хоста из сертификата агента: из URI SAN
+// urn:uuid:<id>, а если его нет - из Common Name
+func HostIDFromCert(cert *x509.Certificate) (uuid.UUID, bool) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme != "urn" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(uri.Opaque, "uuid:"); ok {
+			if id, err := uuid.Parse(rest); err == nil {
+				return id, true
+			}
+		}
+	}
+
+	if id, err := uuid.Parse(cert.Subject.CommonName); err == nil {
+		return id, true
+	}
+
+	return uuid.Nil, false
+}
+
+// PeerHostID возвращает ID хоста из проверенного клиентского сертификата
+// соединения. found сообщает, что сертификат был предъявлен.
+func PeerHostID(state *tls.ConnectionState) (id uuid.UUID, found bool, ok bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return uuid.Nil, false, false
+	}
+
+	id, ok = HostIDFromCert(state.VerifiedChains[0][0])
+	return id, true, ok
+}
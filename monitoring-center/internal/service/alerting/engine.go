@@ -0,0 +1,362 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+var (
+	ErrAlertNotFound     = errors.New("alert not found")
+	ErrInvalidTransition = errors.New("invalid alert state transition")
+)
+
+// RuleStore источник правил оповещений (postgres.AlertRuleRepository)
+type RuleStore interface {
+	FindAll(ctx context.Context, onlyEnabled bool) ([]models.AlertRule, error)
+}
+
+// AlertStore хранилище оповещений (postgres.AlertRepository)
+type AlertStore interface {
+	Create(ctx context.Context, alert *models.Alert) error
+	Update(ctx context.Context, alert *models.Alert) error
+	FindActive(ctx context.Context) ([]models.Alert, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Alert, error)
+}
+
+// HostStore источник хостов, на которые распространяются правила (postgres.HostRepository)
+type HostStore interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Host, error)
+	FindByLabel(ctx context.Context, label string) ([]models.Host, error)
+}
+
+// MetricStore источник метрик хостов (mongo.MetricRepository)
+type MetricStore interface {
+	GetHostMetrics(ctx context.Context, hostID string, metricType models.MetricType, from, to time.Time, limit int64) ([]models.Metric, error)
+}
+
+// Engine непрерывно проверяет включенные правила оповещений по метрикам,
+// которые агенты присылают в MetricRepository, и ведет жизненный цикл
+// оповещений: pending -> firing -> acknowledged -> resolved
+type Engine struct {
+	ruleRepo   RuleStore
+	alertRepo  AlertStore
+	hostRepo   HostStore
+	metricRepo MetricStore
+	interval   time.Duration
+	// Насколько старые метрики еще считаются актуальными
+	lookback time.Duration
+	now      func() time.Time
+
+	// Сериализует изменения состояния оповещений между циклом проверки и API
+	mu        sync.Mutex
+	listeners []func(models.AlertTransition)
+}
+
+func NewEngine(ruleRepo RuleStore, alertRepo AlertStore, hostRepo HostStore, metricRepo MetricStore, interval, lookback time.Duration) *Engine {
+	return &Engine{
+		ruleRepo:   ruleRepo,
+		alertRepo:  alertRepo,
+		hostRepo:   hostRepo,
+		metricRepo: metricRepo,
+		interval:   interval,
+		lookback:   lookback,
+		now:        time.Now,
+	}
+}
+
+// OnTransition регистрирует обработчик переходов оповещений между состояниями
+func (e *Engine) OnTransition(fn func(models.AlertTransition)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners = append(e.listeners, fn)
+}
+
+// Run проверяет правила с заданным интервалом до отмены ctx
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	log.Printf("Alert engine started: evaluating rules every %v", e.interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluateAll(ctx)
+		}
+	}
+}
+
+func (e *Engine) evaluateAll(ctx context.Context) {
+	rules, err := e.ruleRepo.FindAll(ctx, true)
+	if err != nil {
+		log.Printf("Failed to load alert rules: %v", err)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts, err := e.alertRepo.FindActive(ctx)
+	if err != nil {
+		log.Printf("Failed to load active alerts: %v", err)
+		return
+	}
+
+	active := make(map[string]*models.Alert, len(alerts))
+	for i := range alerts {
+		if alerts[i].RuleID != nil {
+			active[alertKey(*alerts[i].RuleID, alerts[i].HostID, alerts[i].Series)] = &alerts[i]
+		}
+	}
+
+	now := e.now()
+	enabled := make(map[uuid.UUID]bool, len(rules))
+	for _, rule := range rules {
+		enabled[rule.ID] = true
+
+		evaluations, err := e.Evaluate(ctx, rule)
+		if err != nil {
+			log.Printf("Failed to evaluate alert rule %q: %v", rule.Name, err)
+			continue
+		}
+
+		for _, evaluation := range evaluations {
+			alert := active[alertKey(rule.ID, evaluation.HostID, evaluation.Series)]
+			if err := e.apply(ctx, rule, evaluation, alert, now); err != nil {
+				log.Printf("Failed to update alert for rule %q on host %s: %v", rule.Name, evaluation.HostName, err)
+			}
+		}
+	}
+
+	// Оповещения удаленных и выключенных правил больше некому разрешить
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.RuleID == nil || !enabled[*alert.RuleID] {
+			if err := e.transition(ctx, alert, models.AlertResolved, now, "", "rule deleted or disabled"); err != nil {
+				log.Printf("Failed to resolve orphaned alert %s: %v", alert.ID, err)
+			}
+		}
+	}
+}
+
+// apply переводит оповещение ряда в состояние, соответствующее результату проверки
+func (e *Engine) apply(ctx context.Context, rule models.AlertRule, evaluation models.RuleEvaluation, alert *models.Alert, now time.Time) error {
+	to := decide(evaluation, alert)
+	if to == "" {
+		return nil
+	}
+	value := *evaluation.Value
+
+	if alert == nil {
+		ruleID := rule.ID
+		alert = &models.Alert{
+			RuleID:       &ruleID,
+			RuleName:     rule.Name,
+			HostID:       evaluation.HostID,
+			Series:       evaluation.Series,
+			Severity:     rule.Severity,
+			State:        models.AlertPending,
+			TriggerValue: value,
+			LastValue:    value,
+			StartedAt:    *evaluation.Since,
+		}
+		if err := e.alertRepo.Create(ctx, alert); err != nil {
+			return err
+		}
+		e.notify(models.AlertTransition{Alert: *alert, To: models.AlertPending})
+	}
+
+	alert.LastValue = value
+	if to == alert.State {
+		return e.alertRepo.Update(ctx, alert)
+	}
+	if to == models.AlertFiring {
+		alert.TriggerValue = value
+	}
+
+	return e.transition(ctx, alert, to, now, "", "")
+}
+
+// decide возвращает состояние, в котором должно оказаться оповещение ряда
+// после проверки. alert - активное оповещение ряда или nil. Пустое состояние -
+// ничего делать не нужно: нет свежих данных или нет ни нарушения, ни оповещения.
+func decide(evaluation models.RuleEvaluation, alert *models.Alert) models.AlertState {
+	// Нет свежих данных - состояние не меняем
+	if evaluation.Value == nil {
+		return ""
+	}
+
+	if !evaluation.Breaching {
+		if alert == nil {
+			return ""
+		}
+		return models.AlertResolved
+	}
+
+	state := models.AlertPending
+	if alert != nil {
+		state = alert.State
+	}
+	// Подтвержденное оповещение остается подтвержденным до разрешения
+	if evaluation.Firing && state == models.AlertPending {
+		return models.AlertFiring
+	}
+	return state
+}
+
+// Acknowledge подтверждает оповещение: оно остается активным до разрешения
+func (e *Engine) Acknowledge(ctx context.Context, id uuid.UUID, by, comment string) (*models.Alert, error) {
+	return e.manualTransition(ctx, id, models.AlertAcknowledged, by, comment)
+}
+
+// Resolve вручную разрешает оповещение. Если условие все еще выполняется,
+// при следующей проверке будет создано новое оповещение.
+func (e *Engine) Resolve(ctx context.Context, id uuid.UUID, by, comment string) (*models.Alert, error) {
+	return e.manualTransition(ctx, id, models.AlertResolved, by, comment)
+}
+
+func (e *Engine) manualTransition(ctx context.Context, id uuid.UUID, to models.AlertState, by, comment string) (*models.Alert, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alert, err := e.alertRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrAlertNotFound
+	}
+
+	if alert.State == models.AlertResolved || alert.State == to {
+		return nil, ErrInvalidTransition
+	}
+
+	if err := e.transition(ctx, alert, to, e.now(), by, comment); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+// transition сохраняет переход оповещения и уведомляет подписчиков
+func (e *Engine) transition(ctx context.Context, alert *models.Alert, to models.AlertState, at time.Time, by, comment string) error {
+	from := alert.State
+	alert.State = to
+	if comment != "" {
+		alert.Comment = comment
+	}
+
+	switch to {
+	case models.AlertFiring:
+		alert.FiredAt = &at
+	case models.AlertAcknowledged:
+		alert.AcknowledgedAt = &at
+		alert.AcknowledgedBy = by
+	case models.AlertResolved:
+		alert.ResolvedAt = &at
+		alert.ResolvedBy = by
+	}
+
+	if err := e.alertRepo.Update(ctx, alert); err != nil {
+		return err
+	}
+
+	log.Printf("Alert %q on host %s %s: %s -> %s", alert.RuleName, alert.HostID, alert.Series, from, to)
+	e.notify(models.AlertTransition{Alert: *alert, From: from, To: to})
+	return nil
+}
+
+// notify вызывается под e.mu
+func (e *Engine) notify(transition models.AlertTransition) {
+	for _, listener := range e.listeners {
+		listener(transition)
+	}
+}
+
+func alertKey(ruleID, hostID uuid.UUID, series string) string {
+	return fmt.Sprintf("%s|%s|%s", ruleID, hostID, series)
+}
+
+// Evaluate проверяет правило по всем хостам, на которые оно распространяется.
+// Ничего не сохраняет, поэтому подходит и для пробной проверки.
+func (e *Engine) Evaluate(ctx context.Context, rule models.AlertRule) ([]models.RuleEvaluation, error) {
+	hosts, err := e.targetHosts(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	now := e.now()
+	from := now.Add(-time.Duration(rule.For) - e.lookback)
+
+	evaluations := []models.RuleEvaluation{}
+	for _, host := range hosts {
+		metrics, err := e.metricRepo.GetHostMetrics(ctx, host.ID.String(), rule.MetricType, from, now, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		series, keys := groupSeries(rule, metrics)
+		if len(keys) == 0 {
+			evaluations = append(evaluations, models.RuleEvaluation{HostID: host.ID, HostName: host.Name})
+			continue
+		}
+
+		for _, key := range keys {
+			evaluation := e.evaluateSeries(rule, series[key], now)
+			evaluation.HostID = host.ID
+			evaluation.HostName = host.Name
+			evaluation.Series = key
+			evaluations = append(evaluations, evaluation)
+		}
+	}
+
+	return evaluations, nil
+}
+
+// evaluateSeries ищет непрерывное нарушение порога, начиная с самого
+// свежего замера. Правило срабатывает, когда нарушение длится не меньше rule.For.
+func (e *Engine) evaluateSeries(rule models.AlertRule, samples []sample, now time.Time) models.RuleEvaluation {
+	evaluation := models.RuleEvaluation{Samples: len(samples)}
+
+	// Без свежих данных правило не срабатывает: молчащий хост - забота liveness-трекера
+	if len(samples) == 0 || now.Sub(samples[0].timestamp) > e.lookback {
+		return evaluation
+	}
+
+	latest := samples[0].value
+	evaluation.Value = &latest
+
+	for _, s := range samples {
+		if !compare(rule.Operator, s.value, rule.Threshold) {
+			break
+		}
+		since := s.timestamp
+		evaluation.Since = &since
+	}
+
+	evaluation.Breaching = evaluation.Since != nil
+	evaluation.Firing = evaluation.Breaching && now.Sub(*evaluation.Since) >= time.Duration(rule.For)
+
+	return evaluation
+}
+
+func (e *Engine) targetHosts(ctx context.Context, rule models.AlertRule) ([]models.Host, error) {
+	if rule.HostID != nil {
+		host, err := e.hostRepo.FindByID(ctx, *rule.HostID)
+		if err != nil || host == nil {
+			return nil, err
+		}
+		return []models.Host{*host}, nil
+	}
+
+	return e.hostRepo.FindByLabel(ctx, rule.HostLabel)
+}
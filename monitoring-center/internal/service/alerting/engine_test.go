@@ -0,0 +1,645 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Интервал между циклами проверки в синтетических рядах
+const cycle = 30 * time.Second
+
+func cpu(value float64) models.Metric {
+	return models.Metric{Type: models.MetricCPU, Value: value}
+}
+
+func disk(mountPoint string, usage float64) models.Metric {
+	return models.Metric{
+		Type: models.MetricDisk,
+		Data: primitive.D{{Key: "mount_point", Value: mountPoint}, {Key: "usage_percent", Value: usage}},
+	}
+}
+
+type fakeRules struct {
+	rules []models.AlertRule
+}
+
+func (f *fakeRules) FindAll(ctx context.Context, onlyEnabled bool) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	for _, rule := range f.rules {
+		if rule.Enabled || !onlyEnabled {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// fakeAlerts хранит копии оповещений, как база: изменения видны только после Update
+type fakeAlerts struct {
+	alerts []models.Alert
+}
+
+func (f *fakeAlerts) Create(ctx context.Context, alert *models.Alert) error {
+	alert.ID = uuid.New()
+	f.alerts = append(f.alerts, *alert)
+	return nil
+}
+
+func (f *fakeAlerts) Update(ctx context.Context, alert *models.Alert) error {
+	for i := range f.alerts {
+		if f.alerts[i].ID == alert.ID {
+			f.alerts[i] = *alert
+			return nil
+		}
+	}
+	return fmt.Errorf("alert %s not found", alert.ID)
+}
+
+func (f *fakeAlerts) FindActive(ctx context.Context) ([]models.Alert, error) {
+	var alerts []models.Alert
+	for _, alert := range f.alerts {
+		if alert.State != models.AlertResolved {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+func (f *fakeAlerts) FindByID(ctx context.Context, id uuid.UUID) (*models.Alert, error) {
+	for _, alert := range f.alerts {
+		if alert.ID == id {
+			return &alert, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeHosts struct {
+	hosts []models.Host
+}
+
+func (f *fakeHosts) FindByID(ctx context.Context, id uuid.UUID) (*models.Host, error) {
+	for _, host := range f.hosts {
+		if host.ID == id {
+			return &host, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeHosts) FindByLabel(ctx context.Context, label string) ([]models.Host, error) {
+	var hosts []models.Host
+	for _, host := range f.hosts {
+		if label == "" || slices.Contains(host.Labels, label) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// fakeMetrics отдает метрики новыми первыми, как MetricRepository
+type fakeMetrics struct {
+	history []models.Metric
+}
+
+func (f *fakeMetrics) GetHostMetrics(ctx context.Context, hostID string, metricType models.MetricType, from, to time.Time, limit int64) ([]models.Metric, error) {
+	var metrics []models.Metric
+	for _, metric := range f.history {
+		if metric.HostID == hostID && metric.Type == metricType &&
+			!metric.Timestamp.Before(from) && !metric.Timestamp.After(to) {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+// step один цикл проверки: метрики, пришедшие к этому циклу, и ожидаемые
+// состояния активных оповещений по рядам после него
+type step struct {
+	metrics []models.Metric
+	ack     []string // Ряды, оповещения которых подтверждаются перед проверкой
+	want    map[string]models.AlertState
+}
+
+// simulation прогоняет Engine.evaluateAll по циклам проверки на хранилищах
+// в памяти: один хост, часы движка сдвигаются на cycle за шаг
+type simulation struct {
+	engine      *Engine
+	rules       *fakeRules
+	alerts      *fakeAlerts
+	metrics     *fakeMetrics
+	host        models.Host
+	now         time.Time
+	step        int
+	transitions []models.AlertTransition
+}
+
+func newSimulation(lookback time.Duration, rules ...models.AlertRule) *simulation {
+	s := &simulation{
+		rules:   &fakeRules{},
+		alerts:  &fakeAlerts{},
+		metrics: &fakeMetrics{},
+		host:    models.Host{ID: uuid.New(), Name: "web-1"},
+		now:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for _, rule := range rules {
+		rule.ID = uuid.New()
+		rule.Enabled = true
+		s.rules.rules = append(s.rules.rules, rule)
+	}
+
+	s.engine = NewEngine(s.rules, s.alerts, &fakeHosts{hosts: []models.Host{s.host}}, s.metrics, cycle, lookback)
+	s.engine.now = func() time.Time { return s.now }
+	s.engine.OnTransition(func(transition models.AlertTransition) {
+		s.transitions = append(s.transitions, transition)
+	})
+	return s
+}
+
+func (s *simulation) run(t *testing.T, steps []step) {
+	t.Helper()
+
+	for _, st := range steps {
+		i := s.step
+		s.step++
+
+		for _, key := range st.ack {
+			alert := s.active(key)
+			if alert == nil {
+				t.Fatalf("step %d: no active alert to acknowledge for series %q", i, key)
+			}
+			if _, err := s.engine.Acknowledge(context.Background(), alert.ID, "alice", ""); err != nil {
+				t.Fatalf("step %d: Acknowledge: %v", i, err)
+			}
+		}
+
+		s.cycle(st.metrics...)
+
+		got := make(map[string]models.AlertState)
+		active, _ := s.alerts.FindActive(context.Background())
+		for _, alert := range active {
+			got[alert.Series] = alert.State
+		}
+		if !sameStates(got, st.want) {
+			t.Fatalf("step %d: alerts %v, want %v (transitions %v)", i, got, st.want, s.log())
+		}
+	}
+}
+
+// cycle добавляет метрики хоста к текущему времени и проверяет правила,
+// после чего часы уходят на следующий цикл
+func (s *simulation) cycle(metrics ...models.Metric) {
+	for _, metric := range metrics {
+		metric.HostID = s.host.ID.String()
+		metric.Timestamp = s.now
+		s.metrics.history = append([]models.Metric{metric}, s.metrics.history...)
+	}
+
+	s.engine.evaluateAll(context.Background())
+	s.now = s.now.Add(cycle)
+}
+
+// active возвращает активное оповещение ряда
+func (s *simulation) active(series string) *models.Alert {
+	active, _ := s.alerts.FindActive(context.Background())
+	for _, alert := range active {
+		if alert.Series == series {
+			return &alert
+		}
+	}
+	return nil
+}
+
+func (s *simulation) log() []string {
+	log := make([]string, 0, len(s.transitions))
+	for _, transition := range s.transitions {
+		if transition.From == "" {
+			log = append(log, fmt.Sprintf("%s: -> %s", transition.Alert.Series, transition.To))
+			continue
+		}
+		log = append(log, fmt.Sprintf("%s: %s -> %s", transition.Alert.Series, transition.From, transition.To))
+	}
+	return log
+}
+
+func sameStates(got, want map[string]models.AlertState) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for key, state := range want {
+		if got[key] != state {
+			return false
+		}
+	}
+	return true
+}
+
+func none() map[string]models.AlertState { return map[string]models.AlertState{} }
+
+func state(s models.AlertState) map[string]models.AlertState {
+	return map[string]models.AlertState{"": s}
+}
+
+func TestEngineStateMachine(t *testing.T) {
+	cpuRule := models.AlertRule{
+		Name:       "high cpu",
+		MetricType: models.MetricCPU,
+		Operator:   ">",
+		Threshold:  80,
+		For:        models.Duration(2 * time.Minute),
+	}
+	immediate := cpuRule
+	immediate.For = 0
+
+	tests := []struct {
+		name        string
+		rule        models.AlertRule
+		steps       []step
+		transitions []string
+	}{
+		{
+			name: "pending, firing, acknowledged, resolved",
+			rule: cpuRule,
+			steps: []step{
+				{metrics: []models.Metric{cpu(50)}, want: none()},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)}, // 0s
+				{metrics: []models.Metric{cpu(91)}, want: state(models.AlertPending)}, // 30s
+				{metrics: []models.Metric{cpu(92)}, want: state(models.AlertPending)}, // 1m
+				{metrics: []models.Metric{cpu(93)}, want: state(models.AlertPending)}, // 1m30s
+				{metrics: []models.Metric{cpu(94)}, want: state(models.AlertFiring)},  // 2m
+				{metrics: []models.Metric{cpu(95)}, want: state(models.AlertFiring)},
+				{metrics: []models.Metric{cpu(96)}, ack: []string{""}, want: state(models.AlertAcknowledged)},
+				{metrics: []models.Metric{cpu(97)}, want: state(models.AlertAcknowledged)},
+				{metrics: []models.Metric{cpu(40)}, want: none()},
+			},
+			transitions: []string{
+				": -> pending",
+				": pending -> firing",
+				": firing -> acknowledged",
+				": acknowledged -> resolved",
+			},
+		},
+		{
+			name: "recovers before for elapses",
+			rule: cpuRule,
+			steps: []step{
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(70)}, want: none()},
+			},
+			transitions: []string{
+				": -> pending",
+				": pending -> resolved",
+			},
+		},
+		{
+			name: "dip below threshold restarts for",
+			rule: cpuRule,
+			steps: []step{
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(80)}, want: none()}, // 80 не больше 80
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				// Без провала здесь уже прошло бы больше 2m
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertFiring)},
+			},
+			transitions: []string{
+				": -> pending",
+				": pending -> resolved",
+				": -> pending",
+				": pending -> firing",
+			},
+		},
+		{
+			name: "zero for fires on first breach",
+			rule: immediate,
+			steps: []step{
+				{metrics: []models.Metric{cpu(10)}, want: none()},
+				{metrics: []models.Metric{cpu(99)}, want: state(models.AlertFiring)},
+				{metrics: []models.Metric{cpu(10)}, want: none()},
+			},
+			transitions: []string{
+				": -> pending",
+				": pending -> firing",
+				": firing -> resolved",
+			},
+		},
+		{
+			name: "no fresh data keeps state",
+			rule: cpuRule,
+			steps: []step{
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertFiring)},
+				// Хост замолчал дольше lookback: оповещение не разрешается и не создается заново
+				{want: state(models.AlertFiring)},
+				{want: state(models.AlertFiring)},
+				{want: state(models.AlertFiring)},
+				{metrics: []models.Metric{cpu(20)}, want: none()},
+			},
+			transitions: []string{
+				": -> pending",
+				": pending -> firing",
+				": firing -> resolved",
+			},
+		},
+		{
+			name: "acknowledged pending alert is not fired",
+			rule: cpuRule,
+			steps: []step{
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)},
+				{metrics: []models.Metric{cpu(90)}, ack: []string{""}, want: state(models.AlertAcknowledged)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertAcknowledged)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertAcknowledged)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertAcknowledged)},
+				{metrics: []models.Metric{cpu(90)}, want: state(models.AlertAcknowledged)},
+			},
+			transitions: []string{
+				": -> pending",
+				": pending -> acknowledged",
+			},
+		},
+		{
+			name: "series keyed by mount point",
+			rule: models.AlertRule{
+				Name:       "disk full",
+				MetricType: models.MetricDisk,
+				Field:      "usage_percent",
+				Operator:   ">=",
+				Threshold:  90,
+				For:        models.Duration(time.Minute),
+			},
+			steps: []step{
+				{
+					metrics: []models.Metric{disk("/", 95), disk("/data", 40)},
+					want:    map[string]models.AlertState{"/": models.AlertPending},
+				},
+				{
+					metrics: []models.Metric{disk("/", 96), disk("/data", 91)},
+					want:    map[string]models.AlertState{"/": models.AlertPending, "/data": models.AlertPending},
+				},
+				{
+					metrics: []models.Metric{disk("/", 97), disk("/data", 92)},
+					want:    map[string]models.AlertState{"/": models.AlertFiring, "/data": models.AlertPending},
+				},
+				{
+					metrics: []models.Metric{disk("/", 50), disk("/data", 93)},
+					want:    map[string]models.AlertState{"/data": models.AlertFiring},
+				},
+			},
+			transitions: []string{
+				"/: -> pending",
+				"/data: -> pending",
+				"/: pending -> firing",
+				"/: firing -> resolved",
+				"/data: pending -> firing",
+			},
+		},
+		{
+			name: "match filters series",
+			rule: models.AlertRule{
+				Name:       "data disk full",
+				MetricType: models.MetricDisk,
+				Field:      "usage_percent",
+				Match:      map[string]string{"mount_point": "/data"},
+				Operator:   ">",
+				Threshold:  90,
+			},
+			steps: []step{
+				{
+					metrics: []models.Metric{disk("/", 99), disk("/data", 95)},
+					want:    map[string]models.AlertState{"/data": models.AlertFiring},
+				},
+			},
+			transitions: []string{
+				"/data: -> pending",
+				"/data: pending -> firing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newSimulation(time.Minute, tt.rule)
+			sim.run(t, tt.steps)
+
+			if got := sim.log(); strings.Join(got, "; ") != strings.Join(tt.transitions, "; ") {
+				t.Errorf("transitions:\n  got  %v\n  want %v", got, tt.transitions)
+			}
+		})
+	}
+}
+
+func TestEvaluateAllTriggerValue(t *testing.T) {
+	sim := newSimulation(time.Minute, models.AlertRule{
+		Name:       "high cpu",
+		MetricType: models.MetricCPU,
+		Operator:   ">",
+		Threshold:  80,
+		For:        models.Duration(time.Minute),
+	})
+	sim.run(t, []step{
+		{metrics: []models.Metric{cpu(90)}, want: state(models.AlertPending)}, // 0s
+		{metrics: []models.Metric{cpu(91)}, want: state(models.AlertPending)}, // 30s
+		{metrics: []models.Metric{cpu(92)}, want: state(models.AlertFiring)},  // 1m
+		{metrics: []models.Metric{cpu(97)}, want: state(models.AlertFiring)},
+	})
+
+	// Значение срабатывания - последнее значение на момент перехода в firing
+	fired := sim.transitions[len(sim.transitions)-1]
+	if fired.To != models.AlertFiring || fired.Alert.TriggerValue != 92 {
+		t.Errorf("last transition to %s with trigger value %v, want firing with 92", fired.To, fired.Alert.TriggerValue)
+	}
+
+	alert := sim.active("")
+	if alert.TriggerValue != 92 || alert.LastValue != 97 {
+		t.Errorf("trigger value %v last value %v, want 92 and 97", alert.TriggerValue, alert.LastValue)
+	}
+}
+
+func TestEvaluateAllNotifiesPendingBeforeFiring(t *testing.T) {
+	sim := newSimulation(time.Minute, models.AlertRule{
+		Name:       "high cpu",
+		MetricType: models.MetricCPU,
+		Operator:   ">",
+		Threshold:  80,
+	})
+	firedAt := sim.now
+	// Без For оповещение создается и срабатывает в одном цикле
+	sim.cycle(cpu(99))
+
+	if len(sim.transitions) != 2 {
+		t.Fatalf("transitions %v, want created pending then firing", sim.log())
+	}
+	created, fired := sim.transitions[0], sim.transitions[1]
+	if created.From != "" || created.To != models.AlertPending || created.Alert.State != models.AlertPending {
+		t.Errorf("first transition %s -> %s with state %s, want creation in pending", created.From, created.To, created.Alert.State)
+	}
+	// Подписчики получают уже сохраненное оповещение
+	if created.Alert.ID == uuid.Nil || created.Alert.ID != fired.Alert.ID {
+		t.Errorf("created alert %s, fired alert %s, want the same stored alert", created.Alert.ID, fired.Alert.ID)
+	}
+	if fired.From != models.AlertPending || fired.To != models.AlertFiring {
+		t.Errorf("second transition %s -> %s, want pending -> firing", fired.From, fired.To)
+	}
+
+	alert := sim.active("")
+	if alert == nil || alert.FiredAt == nil || !alert.FiredAt.Equal(firedAt) || alert.TriggerValue != 99 {
+		t.Errorf("stored alert %+v, want firing since %v with trigger value 99", alert, firedAt)
+	}
+}
+
+func TestEvaluateAllResolvesOrphanedAlerts(t *testing.T) {
+	tests := []struct {
+		name   string
+		orphan func(sim *simulation, alertID uuid.UUID)
+	}{
+		{
+			name: "rule disabled",
+			orphan: func(sim *simulation, alertID uuid.UUID) {
+				sim.rules.rules[0].Enabled = false
+			},
+		},
+		{
+			name: "rule deleted",
+			orphan: func(sim *simulation, alertID uuid.UUID) {
+				sim.rules.rules = nil
+			},
+		},
+		{
+			// Удаление правила в базе обнуляет rule_id его оповещений
+			name: "rule reference cleared",
+			orphan: func(sim *simulation, alertID uuid.UUID) {
+				sim.rules.rules = nil
+				for i := range sim.alerts.alerts {
+					if sim.alerts.alerts[i].ID == alertID {
+						sim.alerts.alerts[i].RuleID = nil
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := newSimulation(time.Minute, models.AlertRule{
+				Name:       "high cpu",
+				MetricType: models.MetricCPU,
+				Operator:   ">",
+				Threshold:  80,
+			})
+			sim.run(t, []step{
+				{metrics: []models.Metric{cpu(99)}, want: state(models.AlertFiring)},
+			})
+			alertID := sim.active("").ID
+
+			tt.orphan(sim, alertID)
+			// Условие все еще нарушается, но проверять его больше некому
+			sim.run(t, []step{
+				{metrics: []models.Metric{cpu(99)}, want: none()},
+			})
+
+			alert, _ := sim.alerts.FindByID(context.Background(), alertID)
+			if alert.ResolvedAt == nil || alert.ResolvedBy != "" || alert.Comment != "rule deleted or disabled" {
+				t.Errorf("alert resolved at %v by %q with comment %q, want automatic resolution of orphan",
+					alert.ResolvedAt, alert.ResolvedBy, alert.Comment)
+			}
+			if got := sim.log(); got[len(got)-1] != ": firing -> resolved" || len(sim.alerts.alerts) != 1 {
+				t.Errorf("transitions %v with %d alerts stored, want firing -> resolved and no new alert", got, len(sim.alerts.alerts))
+			}
+		})
+	}
+}
+
+func TestEvaluateSeries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine := &Engine{lookback: time.Minute}
+	rule := models.AlertRule{Operator: ">", Threshold: 80, For: models.Duration(time.Minute)}
+
+	// Замеры новые первыми, ago - насколько замер старше now
+	series := func(points ...float64) []sample {
+		samples := make([]sample, 0, len(points)/2)
+		for i := 0; i < len(points); i += 2 {
+			samples = append(samples, sample{value: points[i], timestamp: now.Add(-time.Duration(points[i+1]) * time.Second)})
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name      string
+		samples   []sample
+		value     *float64
+		breaching bool
+		since     time.Duration // Длительность нарушения к now
+		firing    bool
+	}{
+		{name: "no samples"},
+		{name: "stale", samples: series(90, 61)},
+		{name: "below threshold", samples: series(50, 0, 90, 30), value: ptr(50)},
+		{name: "breaching shorter than for", samples: series(90, 0, 90, 30, 50, 60), value: ptr(90), breaching: true, since: 30 * time.Second},
+		{name: "breaching exactly for", samples: series(90, 0, 90, 30, 90, 60), value: ptr(90), breaching: true, since: time.Minute, firing: true},
+		{name: "breaching longer than for", samples: series(95, 10, 90, 40, 85, 70, 50, 100), value: ptr(95), breaching: true, since: 70 * time.Second, firing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.evaluateSeries(rule, tt.samples, now)
+
+			if got.Samples != len(tt.samples) {
+				t.Errorf("samples %d, want %d", got.Samples, len(tt.samples))
+			}
+			if (got.Value == nil) != (tt.value == nil) || (got.Value != nil && *got.Value != *tt.value) {
+				t.Errorf("value %v, want %v", deref(got.Value), deref(tt.value))
+			}
+			if got.Breaching != tt.breaching || got.Firing != tt.firing {
+				t.Errorf("breaching %v firing %v, want %v %v", got.Breaching, got.Firing, tt.breaching, tt.firing)
+			}
+			if tt.breaching && (got.Since == nil || now.Sub(*got.Since) != tt.since) {
+				t.Errorf("since %v, want %v before now", got.Since, tt.since)
+			}
+		})
+	}
+}
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		metric models.Metric
+		want   string
+	}{
+		{models.Metric{Type: models.MetricCPU}, ""},
+		{disk("/var", 10), "/var"},
+		{models.Metric{Type: models.MetricProcess, Data: primitive.D{{Key: "name", Value: "nginx"}}}, "nginx"},
+		{models.Metric{Type: models.MetricContainer, Data: map[string]any{"name": "web"}}, "web"},
+		{models.Metric{Type: models.MetricNetwork, Data: primitive.M{"interface": "eth0"}}, "eth0"},
+		{models.Metric{Type: models.MetricPort, Data: primitive.D{{Key: "protocol", Value: "tcp"}, {Key: "port", Value: int32(443)}}}, "tcp/443"},
+	}
+
+	for _, tt := range tests {
+		if got := seriesKey(tt.metric); got != tt.want {
+			t.Errorf("seriesKey(%s) = %q, want %q", tt.metric.Type, got, tt.want)
+		}
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
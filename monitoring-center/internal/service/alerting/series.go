@@ -0,0 +1,146 @@
+package alerting
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type sample struct {
+	value     float64
+	timestamp time.Time
+}
+
+// seriesKey выделяет ряд внутри хоста, чтобы, например, разные точки
+// монтирования одного хоста проверялись независимо
+func seriesKey(metric models.Metric) string {
+	switch metric.Type {
+	case models.MetricDisk:
+		return fieldString(metric.Data, "mount_point")
+	case models.MetricProcess, models.MetricContainer:
+		return fieldString(metric.Data, "name")
+	case models.MetricNetwork:
+		return fieldString(metric.Data, "interface")
+	case models.MetricDiskIO:
+		return fieldString(metric.Data, "device")
+	case models.MetricPressure:
+		return fieldString(metric.Data, "resource")
+	case models.MetricPort:
+		return fmt.Sprintf("%s/%s", fieldString(metric.Data, "protocol"), fieldString(metric.Data, "port"))
+	}
+	return ""
+}
+
+// groupSeries отбирает метрики, подходящие под фильтр правила, и раскладывает
+// их по рядам. Порядок метрик (новые первыми) внутри ряда сохраняется.
+func groupSeries(rule models.AlertRule, metrics []models.Metric) (map[string][]sample, []string) {
+	series := make(map[string][]sample)
+	var keys []string
+
+	for _, metric := range metrics {
+		if !matches(rule.Match, metric.Data) {
+			continue
+		}
+
+		value, ok := metric.Value, true
+		if rule.Field != "" {
+			value, ok = fieldFloat(metric.Data, rule.Field)
+		}
+		if !ok {
+			continue
+		}
+
+		key := seriesKey(metric)
+		if _, exists := series[key]; !exists {
+			keys = append(keys, key)
+		}
+		series[key] = append(series[key], sample{value: value, timestamp: metric.Timestamp})
+	}
+
+	sort.Strings(keys)
+	return series, keys
+}
+
+func matches(match map[string]string, data any) bool {
+	for key, expected := range match {
+		if fieldString(data, key) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// field достает поле из детальных данных метрики. Из MongoDB они
+// приходят как bson.D, при пробной проверке могут быть картой.
+func field(data any, key string) (any, bool) {
+	switch d := data.(type) {
+	case primitive.D:
+		for _, element := range d {
+			if element.Key == key {
+				return element.Value, true
+			}
+		}
+	case primitive.M:
+		value, ok := d[key]
+		return value, ok
+	case map[string]any:
+		value, ok := d[key]
+		return value, ok
+	}
+	return nil, false
+}
+
+func fieldFloat(data any, key string) (float64, bool) {
+	value, ok := field(data, key)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func fieldString(data any, key string) string {
+	value, ok := field(data, key)
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func compare(operator string, value, threshold float64) bool {
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package election
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+// Elector выбирает мастер-хост в каждой группе независимо и хранит его
+// в аренде группы (master_lease).
+// Мастер меняется, только если он перестал быть online или хост с большим
+// приоритетом непрерывно online дольше holdDown. Каждая смена увеличивает
+// term, пишется в master_history и передается подписчикам.
+type Elector struct {
+	hostRepo   *postgres.HostRepository
+	groupRepo  *postgres.HostGroupRepository
+	masterRepo *postgres.MasterRepository
+
+	checkInterval time.Duration
+	leaseTTL      time.Duration
+	holdDown      time.Duration
+
+	// Внеочередная проверка при смене статуса хоста
+	wake chan struct{}
+
+	mu        sync.RWMutex
+	listeners []func(models.MasterChange)
+}
+
+func NewElector(hostRepo *postgres.HostRepository, groupRepo *postgres.HostGroupRepository, masterRepo *postgres.MasterRepository, checkInterval, leaseTTL, holdDown time.Duration) *Elector {
+	return &Elector{
+		hostRepo:      hostRepo,
+		groupRepo:     groupRepo,
+		masterRepo:    masterRepo,
+		checkInterval: checkInterval,
+		leaseTTL:      leaseTTL,
+		holdDown:      holdDown,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// OnMasterChange регистрирует обработчик смены мастера
+func (e *Elector) OnMasterChange(fn func(models.MasterChange)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.listeners = append(e.listeners, fn)
+}
+
+// HostStatusChanged подписывается на liveness.Tracker.OnStatusChange,
+// чтобы не ждать следующего интервала, когда мастер уходит в offline
+func (e *Elector) HostStatusChanged(models.HostStatusChange) {
+	e.Trigger()
+}
+
+// Trigger запрашивает внеочередную проверку, например после изменения состава группы
+func (e *Elector) Trigger() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run проверяет аренду с заданным интервалом до отмены ctx
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.checkInterval)
+	defer ticker.Stop()
+
+	log.Printf("Master elector started: lease TTL %v, hold-down %v", e.leaseTTL, e.holdDown)
+
+	e.check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+		e.check(ctx)
+	}
+}
+
+func (e *Elector) check(ctx context.Context) {
+	groups, err := e.groupRepo.FindAll(ctx)
+	if err != nil {
+		log.Printf("Master election failed: %v", err)
+		return
+	}
+
+	// Выборы в группах независимы: ошибка в одной не мешает остальным
+	e.checkGroup(ctx, models.DefaultGroupID)
+	for _, group := range groups {
+		e.checkGroup(ctx, group.ID)
+	}
+}
+
+func (e *Elector) checkGroup(ctx context.Context, groupID uuid.UUID) {
+	lease, err := e.masterRepo.GetLease(ctx, groupID)
+	if err != nil || lease == nil {
+		// lease == nil - группу удалили между запросами
+		if err != nil {
+			log.Printf("Master election in group %s failed: %v", groupID, err)
+		}
+		return
+	}
+
+	var master *models.Host
+	if lease.HostID != nil {
+		if master, err = e.hostRepo.FindGroupHost(ctx, groupID, *lease.HostID); err != nil {
+			log.Printf("Master election in group %s failed: %v", groupID, err)
+			return
+		}
+	}
+
+	candidate, err := e.hostRepo.FindMasterCandidate(ctx, groupID)
+	if err != nil {
+		log.Printf("Master election in group %s failed: %v", groupID, err)
+		return
+	}
+
+	now := time.Now()
+	next, reason := e.decide(lease, master, candidate, now)
+
+	if reason == "" {
+		if master != nil {
+			if _, err := e.masterRepo.Renew(ctx, groupID, lease.Term, now, now.Add(e.leaseTTL)); err != nil {
+				log.Printf("Failed to renew master lease of group %s: %v", groupID, err)
+			}
+		}
+		return
+	}
+
+	var nextID *uuid.UUID
+	if next != nil {
+		nextID = &next.ID
+	}
+
+	change, err := e.masterRepo.Change(ctx, groupID, lease.Term, nextID, reason, now, now.Add(e.leaseTTL))
+	if err != nil {
+		log.Printf("Failed to change master of group %s: %v", groupID, err)
+		return
+	}
+	// Аренду уже изменили параллельно, решение примем на следующей проверке
+	if change == nil {
+		return
+	}
+
+	e.notify(*change)
+}
+
+// decide возвращает нового мастера и причину смены или пустую причину,
+// если текущий мастер остается
+func (e *Elector) decide(lease *models.MasterLease, master, candidate *models.Host, now time.Time) (*models.Host, string) {
+	if master == nil {
+		switch {
+		case lease.HostID != nil:
+			// Хост из аренды удален или исключен из группы
+			return candidate, models.MasterReasonRemoved
+		case candidate != nil:
+			return candidate, models.MasterReasonElected
+		default:
+			return nil, ""
+		}
+	}
+
+	if master.Status != models.StatusOnline {
+		return candidate, models.MasterReasonOffline
+	}
+
+	// Аренда истекла, пока проверки не шли: агенты уже не считают хост мастером,
+	// поэтому мастер выбирается заново с новым term, даже если это тот же хост
+	if lease.ActiveMaster(now) == nil {
+		if candidate == nil {
+			candidate = master
+		}
+		return candidate, models.MasterReasonExpired
+	}
+
+	if candidate == nil || candidate.ID == master.ID || candidate.Priority <= master.Priority {
+		return nil, ""
+	}
+
+	// Не отдаем мастера хосту, который только что поднялся и может снова упасть
+	if candidate.StatusChangedAt == nil || now.Sub(*candidate.StatusChangedAt) < e.holdDown {
+		return nil, ""
+	}
+
+	return candidate, models.MasterReasonPreempted
+}
+
+func (e *Elector) notify(change models.MasterChange) {
+	log.Printf("Master of group %s changed (term %d, %s): %s -> %s",
+		change.GroupID, change.Term, change.Reason, hostIDString(change.OldHostID), hostIDString(change.NewHostID))
+
+	e.mu.RLock()
+	listeners := e.listeners
+	e.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
+
+func hostIDString(id *uuid.UUID) string {
+	if id == nil {
+		return "none"
+	}
+	return id.String()
+}
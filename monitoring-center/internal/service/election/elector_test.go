@@ -0,0 +1,86 @@
+package election
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+func TestDecide(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	holdDown := 5 * time.Minute
+	elector := &Elector{holdDown: holdDown}
+
+	// host - хост с приоритетом priority, online последние up
+	host := func(priority int, status models.HostStatus, up time.Duration) *models.Host {
+		changedAt := now.Add(-up)
+		return &models.Host{ID: uuid.New(), Name: "host", Priority: priority, Status: status, StatusChangedAt: &changedAt}
+	}
+	// lease - аренда мастера master, истекающая через ttl
+	lease := func(master *models.Host, ttl time.Duration) *models.MasterLease {
+		expiresAt := now.Add(ttl)
+		l := &models.MasterLease{GroupID: models.DefaultGroupID, Term: 7, ExpiresAt: &expiresAt}
+		if master != nil {
+			l.HostID = &master.ID
+		}
+		return l
+	}
+
+	master := host(50, models.StatusOnline, time.Hour)
+	offline := host(50, models.StatusOffline, time.Minute)
+	higher := host(90, models.StatusOnline, time.Hour)
+	justUp := host(90, models.StatusOnline, time.Minute)
+	atHoldDown := host(90, models.StatusOnline, holdDown)
+	lower := host(10, models.StatusOnline, time.Hour)
+	equal := host(50, models.StatusOnline, time.Hour)
+	unknownSince := &models.Host{ID: uuid.New(), Priority: 90, Status: models.StatusOnline}
+
+	tests := []struct {
+		name      string
+		lease     *models.MasterLease
+		master    *models.Host // Хост из аренды, если он еще в группе
+		candidate *models.Host
+		want      *models.Host
+		reason    string
+	}{
+		{name: "vacant without candidates", lease: lease(nil, time.Minute)},
+		{name: "vacant", lease: lease(nil, time.Minute), candidate: higher, want: higher, reason: models.MasterReasonElected},
+		{name: "master removed", lease: lease(master, time.Minute), candidate: higher, want: higher, reason: models.MasterReasonRemoved},
+		{name: "master removed without candidates", lease: lease(master, time.Minute), reason: models.MasterReasonRemoved},
+		{name: "master offline", lease: lease(offline, time.Minute), master: offline, candidate: lower, want: lower, reason: models.MasterReasonOffline},
+		{name: "master offline without candidates", lease: lease(offline, time.Minute), master: offline, reason: models.MasterReasonOffline},
+		// Тот же хост получает аренду заново с новым term
+		{name: "expired, master is the only candidate", lease: lease(master, -time.Second), master: master, want: master, reason: models.MasterReasonExpired},
+		{name: "expired, master is still the best", lease: lease(master, -time.Second), master: master, candidate: master, want: master, reason: models.MasterReasonExpired},
+		{name: "expired with new candidate", lease: lease(master, -time.Second), master: master, candidate: higher, want: higher, reason: models.MasterReasonExpired},
+		{name: "master is the best candidate", lease: lease(master, time.Minute), master: master, candidate: master},
+		{name: "lower priority candidate", lease: lease(master, time.Minute), master: master, candidate: lower},
+		{name: "equal priority candidate", lease: lease(master, time.Minute), master: master, candidate: equal},
+		{name: "higher priority within hold-down", lease: lease(master, time.Minute), master: master, candidate: justUp},
+		{name: "higher priority with unknown uptime", lease: lease(master, time.Minute), master: master, candidate: unknownSince},
+		{name: "preempt at hold-down", lease: lease(master, time.Minute), master: master, candidate: atHoldDown, want: atHoldDown, reason: models.MasterReasonPreempted},
+		{name: "preempt after hold-down", lease: lease(master, time.Minute), master: master, candidate: higher, want: higher, reason: models.MasterReasonPreempted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := elector.decide(tt.lease, tt.master, tt.candidate, now)
+
+			if got != tt.want || reason != tt.reason {
+				t.Errorf("decide = %s, %q; want %s, %q", hostName(got, master), reason, hostName(tt.want, master), tt.reason)
+			}
+		})
+	}
+}
+
+func hostName(host, master *models.Host) string {
+	switch {
+	case host == nil:
+		return "none"
+	case host == master:
+		return "master"
+	}
+	return host.ID.String()
+}
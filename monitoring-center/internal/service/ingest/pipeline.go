@@ -0,0 +1,227 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/mongo"
+)
+
+var (
+	// ErrQueueFull очередь заполнена, агенту нужно повторить отправку позже
+	ErrQueueFull = errors.New("ingest queue is full")
+	// ErrClosed прием остановлен при завершении ЦМ
+	ErrClosed = errors.New("ingest pipeline is closed")
+)
+
+// Повторы записи при недоступности MongoDB: задержка удваивается с каждой
+// попыткой до maxWriteBackoff
+const (
+	writeBackoff    = time.Second
+	maxWriteBackoff = 30 * time.Second
+)
+
+// MetricStore хранилище, в которое очередь записывает метрики
+type MetricStore interface {
+	InsertMetrics(ctx context.Context, documents []interface{}) error
+}
+
+// Pipeline принимает проверенные пакеты метрик в ограниченную очередь и
+// сохраняет их в фоне. Воркеры объединяют пакеты из очереди в крупные
+// записи в MongoDB. Принятые пакеты уже подтверждены агентам, поэтому при
+// недоступности MongoDB запись повторяется, пока база не вернется: воркеры
+// стоят, очередь заполняется, и агенты получают 429. При остановке принятые
+// пакеты дописываются, а не отбрасываются.
+type Pipeline struct {
+	store MetricStore
+	queue chan models.MetricsRequest
+
+	workers       int
+	batchSize     int           // Метрик в одной записи, при наборе запись уходит сразу
+	flushInterval time.Duration // Неполная запись уходит не позже этого времени
+	writeTimeout  time.Duration // Ограничение одной попытки записи
+	retryBackoff  time.Duration // Задержка перед первым повтором записи
+
+	// Защищает закрытие очереди от одновременной постановки пакета
+	mu     sync.RWMutex
+	closed bool
+
+	// Закрывается, когда остановка не дождалась записи: повторы прекращаются
+	abort     chan struct{}
+	abortOnce sync.Once
+
+	wg sync.WaitGroup
+}
+
+func NewPipeline(store MetricStore, queueSize, workers, batchSize int, flushInterval, writeTimeout time.Duration) *Pipeline {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	if writeTimeout <= 0 {
+		writeTimeout = 10 * time.Second
+	}
+
+	return &Pipeline{
+		store:         store,
+		queue:         make(chan models.MetricsRequest, queueSize),
+		workers:       workers,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		writeTimeout:  writeTimeout,
+		retryBackoff:  writeBackoff,
+		abort:         make(chan struct{}),
+	}
+}
+
+// Start запускает воркеры. Они работают до Shutdown.
+func (p *Pipeline) Start() {
+	log.Printf("Ingest pipeline started: %d workers, queue of %d batches, up to %d metrics per write",
+		p.workers, cap(p.queue), p.batchSize)
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+}
+
+// Enqueue ставит пакет в очередь, не блокируясь. Возвращает ErrQueueFull,
+// если очередь заполнена, и ErrClosed после Shutdown.
+func (p *Pipeline) Enqueue(batch models.MetricsRequest) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.queue <- batch:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Len возвращает число пакетов в очереди
+func (p *Pipeline) Len() int {
+	return len(p.queue)
+}
+
+// Shutdown прекращает прием пакетов и ждет, пока воркеры запишут уже
+// принятые. ctx ограничивает ожидание: по его истечении повторы записи
+// прекращаются, а оставшиеся пакеты отбрасываются.
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.abortOnce.Do(func() { close(p.abort) })
+		return fmt.Errorf("ingest queue not drained, %d batches left: %w", len(p.queue), ctx.Err())
+	}
+}
+
+// worker копит пакеты из очереди и записывает их, когда набралось batchSize
+// метрик или прошло flushInterval. После закрытия очереди дочитывает ее до конца.
+func (p *Pipeline) worker() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	var pending []models.MetricsRequest
+	count := 0
+
+	flush := func() {
+		if len(pending) > 0 {
+			p.write(pending, count)
+		}
+		pending = nil
+		count = 0
+	}
+
+	for {
+		select {
+		case batch, ok := <-p.queue:
+			if !ok {
+				flush()
+				return
+			}
+			pending = append(pending, batch)
+			count += len(batch.Metrics)
+			if count >= p.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write сохраняет пакеты одной записью. Контекст не связан с завершением ЦМ,
+// иначе при остановке очередь терялась бы.
+func (p *Pipeline) write(batches []models.MetricsRequest, count int) {
+	// Документы с _id готовятся один раз: если запись прошла, но ответ не дошел,
+	// повтор получит дубликаты ключа вместо второй копии метрик
+	documents := mongo.PrepareMetrics(batches...)
+
+	backoff := p.retryBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-p.abort:
+			log.Printf("Dropping %d metrics from %d batches: shutdown did not wait for MongoDB", count, len(batches))
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.writeTimeout)
+		err := p.store.InsertMetrics(ctx, documents)
+		cancel()
+
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("Wrote %d metrics after %d attempts", count, attempt)
+			}
+			return
+		}
+
+		// Ошибка самих документов повтором не исправится
+		if mongo.IsDocumentError(err) {
+			log.Printf("Dropping %d metrics from %d batches: %v", count, len(batches), err)
+			return
+		}
+
+		log.Printf("Failed to write %d metrics (attempt %d), retrying in %v: %v", count, attempt, backoff, err)
+		select {
+		case <-p.abort:
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxWriteBackoff)
+	}
+}
@@ -0,0 +1,271 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeStore запоминает записи и отвечает ошибками из fail, пока тот их возвращает
+type fakeStore struct {
+	mu     sync.Mutex
+	writes []int // Число документов в каждой успешной записи
+	calls  int
+	fail   func(call int) error
+}
+
+func (s *fakeStore) InsertMetrics(ctx context.Context, documents []interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.fail != nil {
+		if err := s.fail(s.calls); err != nil {
+			return err
+		}
+	}
+	s.writes = append(s.writes, len(documents))
+	return nil
+}
+
+func (s *fakeStore) snapshot() (writes []int, calls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.writes...), s.calls
+}
+
+func (s *fakeStore) written() int {
+	writes, _ := s.snapshot()
+	total := 0
+	for _, n := range writes {
+		total += n
+	}
+	return total
+}
+
+func batch(metrics int) models.MetricsRequest {
+	request := models.MetricsRequest{HostID: "host", Timestamp: time.Now()}
+	for i := 0; i < metrics; i++ {
+		request.Metrics = append(request.Metrics, models.Metric{Type: models.MetricCPU, Value: float64(i)})
+	}
+	return request
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func shutdown(t *testing.T, p *Pipeline) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestEnqueueQueueFull(t *testing.T) {
+	// Воркеры не запущены, очередь только заполняется
+	p := NewPipeline(&fakeStore{}, 2, 1, 10, time.Hour, time.Second)
+
+	for i := 0; i < 2; i++ {
+		if err := p.Enqueue(batch(1)); err != nil {
+			t.Fatalf("Enqueue %d: %v", i, err)
+		}
+	}
+	if err := p.Enqueue(batch(1)); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Enqueue on full queue: %v, want ErrQueueFull", err)
+	}
+	if p.Len() != 2 {
+		t.Errorf("Len %d, want 2", p.Len())
+	}
+}
+
+func TestEnqueueAfterShutdown(t *testing.T) {
+	p := NewPipeline(&fakeStore{}, 2, 1, 10, time.Hour, time.Second)
+	p.Start()
+	shutdown(t, p)
+
+	if err := p.Enqueue(batch(1)); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Enqueue after Shutdown: %v, want ErrClosed", err)
+	}
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	store := &fakeStore{}
+	// Ни размер, ни интервал записи не наступят до остановки
+	p := NewPipeline(store, 100, 3, 1000, time.Hour, time.Second)
+
+	for i := 0; i < 50; i++ {
+		if err := p.Enqueue(batch(2)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	p.Start()
+	shutdown(t, p)
+
+	if got := store.written(); got != 100 {
+		t.Errorf("written %d metrics, want 100", got)
+	}
+}
+
+func TestCoalesceByBatchSize(t *testing.T) {
+	store := &fakeStore{}
+	p := NewPipeline(store, 100, 1, 5, time.Hour, time.Second)
+	p.Start()
+	defer shutdown(t, p)
+
+	// 2+2 меньше batchSize, третий пакет добирает до 6 - уходит одна запись
+	for _, n := range []int{2, 2, 2} {
+		if err := p.Enqueue(batch(n)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	waitFor(t, func() bool { return store.written() == 6 })
+
+	// Неполная запись ждет flushInterval
+	if err := p.Enqueue(batch(1)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	writes, _ := store.snapshot()
+	if len(writes) != 1 || writes[0] != 6 {
+		t.Errorf("writes %v, want [6]", writes)
+	}
+}
+
+func TestCoalesceByFlushInterval(t *testing.T) {
+	store := &fakeStore{}
+	p := NewPipeline(store, 100, 1, 1000, 20*time.Millisecond, time.Second)
+	p.Start()
+	defer shutdown(t, p)
+
+	for _, n := range []int{1, 2, 3} {
+		if err := p.Enqueue(batch(n)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	waitFor(t, func() bool { return store.written() == 6 })
+
+	writes, _ := store.snapshot()
+	if len(writes) != 1 {
+		t.Errorf("writes %v, want a single write of 6 metrics", writes)
+	}
+}
+
+func TestRetryUntilStoreRecovers(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	store := &fakeStore{fail: func(int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			return errors.New("server selection error: connection refused")
+		}
+		return nil
+	}}
+
+	p := NewPipeline(store, 1, 1, 1, time.Hour, time.Second)
+	p.retryBackoff = time.Millisecond
+	p.Start()
+
+	if err := p.Enqueue(batch(3)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Запись повторяется дольше прежних трех попыток и метрики не теряются
+	waitFor(t, func() bool {
+		_, calls := store.snapshot()
+		return calls >= 5
+	})
+
+	// Воркер стоит на повторах, очередь заполняется - агенты получают 429
+	if err := p.Enqueue(batch(1)); err != nil {
+		t.Fatalf("Enqueue into empty queue: %v", err)
+	}
+	if err := p.Enqueue(batch(1)); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Enqueue while store is down: %v, want ErrQueueFull", err)
+	}
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+
+	shutdown(t, p)
+	if got := store.written(); got != 4 {
+		t.Errorf("written %d metrics, want 4", got)
+	}
+}
+
+func TestDocumentErrorIsNotRetried(t *testing.T) {
+	store := &fakeStore{fail: func(call int) error {
+		if call == 1 {
+			return mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 121}}}}
+		}
+		return nil
+	}}
+
+	p := NewPipeline(store, 10, 1, 1, time.Hour, time.Second)
+	p.retryBackoff = time.Millisecond
+	p.Start()
+
+	if err := p.Enqueue(batch(1)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := p.Enqueue(batch(2)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	shutdown(t, p)
+
+	writes, calls := store.snapshot()
+	if calls != 2 || len(writes) != 1 || writes[0] != 2 {
+		t.Errorf("calls %d writes %v, want 2 calls and writes [2]", calls, writes)
+	}
+}
+
+func TestShutdownTimeoutStopsRetries(t *testing.T) {
+	store := &fakeStore{fail: func(int) error { return context.DeadlineExceeded }}
+
+	p := NewPipeline(store, 10, 1, 1, time.Hour, time.Second)
+	p.retryBackoff = time.Millisecond
+	p.Start()
+
+	if err := p.Enqueue(batch(1)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	waitFor(t, func() bool {
+		_, calls := store.snapshot()
+		return calls >= 2
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown: %v, want deadline exceeded", err)
+	}
+
+	// После отказа от ожидания воркеры перестают повторять запись
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("workers kept retrying after shutdown timed out")
+	}
+}
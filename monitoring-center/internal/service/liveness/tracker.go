@@ -0,0 +1,147 @@
+package liveness
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+// Таймаут записи времени приема метрик
+const flushTimeout = 5 * time.Second
+
+// Tracker следит за тем, присылают ли агенты метрики, и поддерживает
+// hosts.status: хост становится online при приеме метрик и offline,
+// если метрик не было дольше заданного числа интервалов опроса.
+// Время приема копится в памяти и раз в checkInterval записывается
+// в PostgreSQL одним запросом, поэтому прием метрик не ждет БД.
+type Tracker struct {
+	hostRepo      *postgres.HostRepository
+	timeout       time.Duration
+	checkInterval time.Duration
+	startedAt     time.Time
+
+	seen sync.Map // uuid.UUID -> time.Time, еще не записанное в БД
+
+	mu        sync.RWMutex
+	listeners []func(models.HostStatusChange)
+}
+
+func NewTracker(hostRepo *postgres.HostRepository, pollingInterval time.Duration, missedIntervals int, checkInterval time.Duration) *Tracker {
+	if missedIntervals < 1 {
+		missedIntervals = 1
+	}
+
+	return &Tracker{
+		hostRepo:      hostRepo,
+		timeout:       pollingInterval * time.Duration(missedIntervals),
+		checkInterval: checkInterval,
+		startedAt:     time.Now(),
+	}
+}
+
+// OnStatusChange регистрирует обработчик переходов статуса хостов
+func (t *Tracker) OnStatusChange(fn func(models.HostStatusChange)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listeners = append(t.listeners, fn)
+}
+
+// RecordSeen отмечает прием метрик от хоста. Хост переходит в online
+// при следующей записи в Run.
+func (t *Tracker) RecordSeen(hostID uuid.UUID) {
+	t.seen.Store(hostID, time.Now())
+}
+
+// Run периодически записывает время приема метрик и переводит молчащие
+// хосты в offline до отмены ctx. При отмене записывает накопленное.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.checkInterval)
+	defer ticker.Stop()
+
+	log.Printf("Liveness tracker started: offline after %v without metrics", t.timeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			// Сначала запись, чтобы присылающие метрики хосты не ушли в offline
+			t.flush(ctx)
+			t.check(ctx)
+		}
+	}
+}
+
+// flush записывает накопленное время приема метрик и сообщает о хостах,
+// вернувшихся в online
+func (t *Tracker) flush(ctx context.Context) {
+	var ids []uuid.UUID
+	var times []time.Time
+	t.seen.Range(func(key, value any) bool {
+		ids = append(ids, key.(uuid.UUID))
+		times = append(times, value.(time.Time))
+		// Если хост успел прислать метрики еще раз, новое время останется до следующей записи
+		t.seen.CompareAndDelete(key, value)
+		return true
+	})
+
+	if len(ids) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, flushTimeout)
+	defer cancel()
+
+	changes, err := t.hostRepo.MarkSeenBatch(ctx, ids, times)
+	if err != nil {
+		log.Printf("Failed to record %d hosts as seen: %v", len(ids), err)
+
+		// Вернем время в очередь, если его не заменило более новое
+		for i, id := range ids {
+			t.seen.LoadOrStore(id, times[i])
+		}
+		return
+	}
+
+	for _, change := range changes {
+		t.notify(change)
+	}
+}
+
+func (t *Tracker) check(ctx context.Context) {
+	now := time.Now()
+
+	// После рестарта ЦМ агентам нужно дать время прислать метрики,
+	// иначе все хосты разом уйдут в offline
+	if now.Sub(t.startedAt) < t.timeout {
+		return
+	}
+
+	changes, err := t.hostRepo.MarkOfflineNotSeenSince(ctx, now.Add(-t.timeout), now)
+	if err != nil {
+		log.Printf("Liveness check failed: %v", err)
+		return
+	}
+
+	for _, change := range changes {
+		t.notify(change)
+	}
+}
+
+func (t *Tracker) notify(change models.HostStatusChange) {
+	log.Printf("Host %s status changed: %s -> %s", change.HostID, change.OldStatus, change.NewStatus)
+
+	t.mu.RLock()
+	listeners := t.listeners
+	t.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
@@ -0,0 +1,298 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+const queueSize = 256
+
+// event событие liveness-трекера, движка оповещений или выбора мастера, ожидающее отправки
+type event struct {
+	status *models.HostStatusChange
+	alert  *models.AlertTransition
+	master *models.MasterChange
+}
+
+// Dispatcher рассылает уведомления о смене статуса хостов и переходах
+// оповещений по подписанным каналам. Обработчики событий не блокируются:
+// события ставятся в очередь, доставка с повторами идет в фоне.
+type Dispatcher struct {
+	repo      *postgres.NotificationRepository
+	hostRepo  *postgres.HostRepository
+	groupRepo *postgres.HostGroupRepository
+	queue     chan event
+
+	maxAttempts int
+	backoff     time.Duration // Задержка перед второй попыткой, далее удваивается
+	timeout     time.Duration // Ограничение одной попытки
+
+	wg sync.WaitGroup
+}
+
+func NewDispatcher(repo *postgres.NotificationRepository, hostRepo *postgres.HostRepository, groupRepo *postgres.HostGroupRepository, maxAttempts int, backoff, timeout time.Duration) *Dispatcher {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &Dispatcher{
+		repo:        repo,
+		hostRepo:    hostRepo,
+		groupRepo:   groupRepo,
+		queue:       make(chan event, queueSize),
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		timeout:     timeout,
+	}
+}
+
+// HostStatusChanged подписывается на liveness.Tracker.OnStatusChange
+func (d *Dispatcher) HostStatusChanged(change models.HostStatusChange) {
+	d.enqueue(event{status: &change})
+}
+
+// AlertTransitioned подписывается на alerting.Engine.OnTransition
+func (d *Dispatcher) AlertTransitioned(transition models.AlertTransition) {
+	// Pending еще может разрешиться сам, а разрешение такого оповещения никому не интересно
+	if transition.To == models.AlertPending ||
+		(transition.To == models.AlertResolved && transition.From == models.AlertPending) {
+		return
+	}
+	d.enqueue(event{alert: &transition})
+}
+
+// MasterChanged подписывается на election.Elector.OnMasterChange
+func (d *Dispatcher) MasterChanged(change models.MasterChange) {
+	d.enqueue(event{master: &change})
+}
+
+func (d *Dispatcher) enqueue(e event) {
+	select {
+	case d.queue <- e:
+	default:
+		log.Printf("Notification queue is full, dropping event")
+	}
+}
+
+// Run обрабатывает очередь до отмены ctx и дожидается начатых доставок
+func (d *Dispatcher) Run(ctx context.Context) {
+	defer d.wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.queue:
+			d.dispatch(ctx, e)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, e event) {
+	var msg Message
+	switch {
+	case e.status != nil:
+		msg = d.hostStatusMessage(ctx, *e.status)
+	case e.alert != nil:
+		msg = d.alertMessage(ctx, *e.alert)
+	case e.master != nil:
+		msg = d.masterMessage(ctx, *e.master)
+	}
+
+	channels, err := d.repo.FindChannels(ctx, true)
+	if err != nil {
+		log.Printf("Failed to load notification channels: %v", err)
+		return
+	}
+
+	for _, channel := range channels {
+		if !channel.Subscribed(msg.Event, msg.Severity) {
+			continue
+		}
+
+		// Каналы доставляются независимо: медленный SMTP не задерживает webhook
+		d.wg.Add(1)
+		go func(channel models.NotificationChannel) {
+			defer d.wg.Done()
+			d.Deliver(ctx, channel, msg)
+		}(channel)
+	}
+}
+
+// Deliver отправляет сообщение в канал с повторами и записывает результат в журнал
+func (d *Dispatcher) Deliver(ctx context.Context, channel models.NotificationChannel, msg Message) *models.NotificationDelivery {
+	delivery := &models.NotificationDelivery{
+		ChannelID: channel.ID,
+		Event:     msg.Event,
+		Subject:   msg.Subject,
+		Status:    models.DeliveryFailed,
+		CreatedAt: time.Now(),
+	}
+
+	n, err := New(channel.Type, channel.Config)
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		backoff := d.backoff
+		for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+			delivery.Attempts = attempt
+
+			err = d.send(ctx, n, msg)
+			if err == nil {
+				delivery.Status = models.DeliverySent
+				delivery.Error = ""
+				break
+			}
+			delivery.Error = err.Error()
+
+			if attempt == d.maxAttempts || !sleep(ctx, backoff) {
+				break
+			}
+			backoff *= 2
+		}
+	}
+
+	if delivery.Status == models.DeliveryFailed {
+		log.Printf("Failed to deliver %q to channel %q after %d attempts: %s",
+			msg.Subject, channel.Name, delivery.Attempts, delivery.Error)
+	}
+
+	// Журнал пишем и при остановке приложения, поэтому без отмененного ctx
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := d.repo.LogDelivery(logCtx, delivery); err != nil {
+		log.Printf("Failed to log notification delivery: %v", err)
+	}
+
+	return delivery
+}
+
+func (d *Dispatcher) send(ctx context.Context, n Notifier, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	return n.Send(ctx, msg)
+}
+
+// sleep ждет d или отмены ctx и сообщает, дождался ли
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// TestMessage сообщение для проверки настроек канала
+func TestMessage(channel models.NotificationChannel) Message {
+	return Message{
+		Event:   models.EventTest,
+		Subject: fmt.Sprintf("Test notification for channel %q", channel.Name),
+		Text:    "If you see this, the monitoring center can deliver notifications to this channel.",
+		Time:    time.Now(),
+	}
+}
+
+func (d *Dispatcher) hostStatusMessage(ctx context.Context, change models.HostStatusChange) Message {
+	name := d.hostName(ctx, change.HostID)
+
+	severity := models.SeverityInfo
+	if change.NewStatus == models.StatusOffline {
+		severity = models.SeverityCritical
+	}
+
+	return Message{
+		Event:    models.EventHostStatus,
+		Severity: severity,
+		Subject:  fmt.Sprintf("[%s] Host %s is %s", strings.ToUpper(string(change.NewStatus)), name, change.NewStatus),
+		Text: fmt.Sprintf("Host %s (%s) changed status from %s to %s at %s.",
+			name, change.HostID, change.OldStatus, change.NewStatus, change.ChangedAt.Format(time.RFC3339)),
+		Time:    change.ChangedAt,
+		Payload: change,
+	}
+}
+
+func (d *Dispatcher) alertMessage(ctx context.Context, transition models.AlertTransition) Message {
+	alert := transition.Alert
+	name := d.hostName(ctx, alert.HostID)
+
+	target := name
+	if alert.Series != "" {
+		target = fmt.Sprintf("%s (%s)", name, alert.Series)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Rule: %s\nHost: %s\nSeverity: %s\nState: %s -> %s\n",
+		alert.RuleName, target, alert.Severity, transition.From, transition.To)
+	fmt.Fprintf(&text, "Trigger value: %g\nLast value: %g\nStarted at: %s\n",
+		alert.TriggerValue, alert.LastValue, alert.StartedAt.Format(time.RFC3339))
+	if alert.AcknowledgedBy != "" && transition.To == models.AlertAcknowledged {
+		fmt.Fprintf(&text, "Acknowledged by: %s\n", alert.AcknowledgedBy)
+	}
+	if alert.ResolvedBy != "" && transition.To == models.AlertResolved {
+		fmt.Fprintf(&text, "Resolved by: %s\n", alert.ResolvedBy)
+	}
+	if alert.Comment != "" {
+		fmt.Fprintf(&text, "Comment: %s\n", alert.Comment)
+	}
+
+	return Message{
+		Event:    models.EventAlert,
+		Severity: alert.Severity,
+		Subject:  fmt.Sprintf("[%s] %s on %s", strings.ToUpper(string(transition.To)), alert.RuleName, target),
+		Text:     strings.TrimRight(text.String(), "\n"),
+		Time:     alert.UpdatedAt,
+		Payload:  transition,
+	}
+}
+
+func (d *Dispatcher) masterMessage(ctx context.Context, change models.MasterChange) Message {
+	oldName, newName := "none", "none"
+	group := "default group"
+	if change.GroupID != models.DefaultGroupID {
+		group = "group " + change.GroupID.String()
+		if g, err := d.groupRepo.FindByID(ctx, change.GroupID); err == nil && g != nil {
+			group = "group " + g.Name
+		}
+	}
+	if change.OldHostID != nil {
+		oldName = d.hostName(ctx, *change.OldHostID)
+	}
+
+	severity := models.SeverityWarning
+	if change.NewHostID != nil {
+		newName = d.hostName(ctx, *change.NewHostID)
+	} else {
+		severity = models.SeverityCritical
+	}
+
+	return Message{
+		Event:    models.EventMaster,
+		Severity: severity,
+		Subject:  fmt.Sprintf("[MASTER] %s is the new master of %s (term %d)", newName, group, change.Term),
+		Text: fmt.Sprintf("Master of %s changed from %s to %s at %s.\nReason: %s\nTerm: %d",
+			group, oldName, newName, change.ChangedAt.Format(time.RFC3339), change.Reason, change.Term),
+		Time:    change.ChangedAt,
+		Payload: change,
+	}
+}
+
+// hostName возвращает имя хоста или его ID, если хост не найден
+func (d *Dispatcher) hostName(ctx context.Context, id uuid.UUID) string {
+	host, err := d.hostRepo.FindByID(ctx, id)
+	if err != nil || host == nil {
+		return id.String()
+	}
+	return host.Name
+}
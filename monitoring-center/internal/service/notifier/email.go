@@ -0,0 +1,138 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EmailConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	// Неявный TLS (обычно порт 465). Иначе STARTTLS, если сервер его поддерживает
+	TLS bool `json:"tls"`
+}
+
+// Email отправляет уведомления письмом через SMTP
+type Email struct {
+	config EmailConfig
+}
+
+func NewEmail(config []byte) (*Email, error) {
+	var cfg EmailConfig
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 25
+	}
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("port must be between 1 and 65535")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("from must be an email address")
+	}
+	if len(cfg.To) == 0 {
+		return nil, fmt.Errorf("to must contain at least one address")
+	}
+	for _, to := range cfg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("to contains invalid address %q", to)
+		}
+	}
+
+	return &Email{config: cfg}, nil
+}
+
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	tlsConfig := &tls.Config{ServerName: e.config.Host}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	// net/smtp не принимает контекст, поэтому ограничиваем сессию дедлайном
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if e.config.TLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !e.config.TLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+
+	if e.config.Username != "" {
+		auth := smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(e.config.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, to := range e.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(e.compose(msg)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (e *Email) compose(msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", msg.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+// Message уведомление, не зависящее от канала доставки
+type Message struct {
+	Event    models.NotificationEvent `json:"event"`
+	Severity models.AlertSeverity     `json:"severity,omitempty"`
+	Subject  string                   `json:"subject"`
+	Text     string                   `json:"text"`
+	Time     time.Time                `json:"time"`
+	// Исходное событие: models.HostStatusChange или models.AlertTransition
+	Payload any `json:"payload,omitempty"`
+}
+
+// Notifier доставляет уведомление в один канал
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New создает Notifier по типу и настройкам канала.
+// Используется и для проверки настроек при сохранении канала.
+func New(channelType models.ChannelType, config json.RawMessage) (Notifier, error) {
+	switch channelType {
+	case models.ChannelWebhook:
+		return NewWebhook(config)
+	case models.ChannelEmail:
+		return NewEmail(config)
+	case models.ChannelTelegram:
+		return NewTelegram(config)
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", channelType)
+	}
+}
+
+func decodeConfig(config json.RawMessage, target any) error {
+	if err := json.Unmarshal(config, target); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
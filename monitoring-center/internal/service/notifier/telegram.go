@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultTelegramURL = "https://api.telegram.org"
+
+type TelegramConfig struct {
+	// Адрес Bot API. Можно подменить локальной заглушкой
+	BaseURL string `json:"base_url"`
+	Token   string `json:"token"`
+	ChatID  string `json:"chat_id"`
+}
+
+// Telegram отправляет уведомления методом sendMessage Bot API
+type Telegram struct {
+	config TelegramConfig
+	client *http.Client
+}
+
+func NewTelegram(config []byte) (*Telegram, error) {
+	var cfg TelegramConfig
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultTelegramURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if u, err := url.Parse(cfg.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("base_url must be an absolute http(s) URL")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("token is required")
+	}
+	if cfg.ChatID == "" {
+		return nil, fmt.Errorf("chat_id is required")
+	}
+
+	return &Telegram{config: cfg, client: &http.Client{}}, nil
+}
+
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  t.config.ChatID,
+		"text":                     msg.Subject + "\n\n" + msg.Text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", t.config.BaseURL, t.config.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Токен входит в URL и не должен попасть в журнал доставки
+	if err := do(t.client, req); err != nil {
+		return errors.New(strings.ReplaceAll(err.Error(), t.config.Token, "<token>"))
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+// Webhook отправляет Message как JSON методом POST
+type Webhook struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+func NewWebhook(config []byte) (*Webhook, error) {
+	var cfg WebhookConfig
+	if err := decodeConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL")
+	}
+
+	return &Webhook{config: cfg, client: &http.Client{}}, nil
+}
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	return do(w.client, req)
+}
+
+// do выполняет запрос и считает ошибкой любой ответ, кроме 2xx
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
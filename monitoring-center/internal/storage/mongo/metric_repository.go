@@ -2,15 +2,20 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/nekitmilk/monitoring-center/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Код ошибки MongoDB при нарушении уникального индекса
+const duplicateKeyCode = 11000
+
 type MetricRepository struct {
 	collection *mongo.Collection
 }
@@ -44,31 +49,59 @@ func (r *MetricRepository) CreateIndexes(ctx context.Context) error {
 	return err
 }
 
-// SaveMetrics сохраняет метрики от агента
-func (r *MetricRepository) SaveMetrics(ctx context.Context, req models.MetricsRequest) error {
-	if len(req.Metrics) == 0 {
-		return nil
-	}
-
+// PrepareMetrics превращает пакеты агентов в документы для записи. _id
+// назначается здесь, один раз: повтор записи тех же документов не задвоит метрики.
+func PrepareMetrics(batches ...models.MetricsRequest) []interface{} {
 	var documents []interface{}
-	for _, metric := range req.Metrics {
-		metric.HostID = req.HostID
-		if req.Timestamp.IsZero() {
-			metric.Timestamp = time.Now()
-		} else {
-			metric.Timestamp = req.Timestamp
+	for _, req := range batches {
+		for _, metric := range req.Metrics {
+			metric.ID = primitive.NewObjectID()
+			metric.HostID = req.HostID
+			// Коллекторы агента работают по своим расписаниям, поэтому время
+			// метрики может отличаться от времени пакета
+			if metric.Timestamp.IsZero() {
+				metric.Timestamp = req.Timestamp
+			}
+			if metric.Timestamp.IsZero() {
+				metric.Timestamp = time.Now()
+			}
+			documents = append(documents, metric)
 		}
-		documents = append(documents, metric)
 	}
+	return documents
+}
 
-	_, err := r.collection.InsertMany(ctx, documents)
-	if err != nil {
+// InsertMetrics записывает документы из PrepareMetrics. Запись неупорядоченная:
+// ошибка в одном документе не мешает остальным. Дубликаты _id ошибкой не считаются,
+// это документы, уже записанные прошлой попыткой, ответ на которую не дошел.
+func (r *MetricRepository) InsertMetrics(ctx context.Context, documents []interface{}) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeys(err) {
 		return fmt.Errorf("failed to insert metrics: %w", err)
 	}
 
 	return nil
 }
 
+// onlyDuplicateKeys сообщает, что все ошибки записи - дубликаты ключа
+func onlyDuplicateKeys(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != duplicateKeyCode {
+			return false
+		}
+	}
+	return true
+}
+
 // GetHostMetrics возвращает метрики для конкретного хоста
 func (r *MetricRepository) GetHostMetrics(ctx context.Context, hostID string, metricType models.MetricType, from, to time.Time, limit int64) ([]models.Metric, error) {
 	filter := bson.M{
@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -44,3 +45,11 @@ func (s *MongoStorage) GetDatabase(name string) *mongo.Database {
 func (s *MongoStorage) GetCollection(dbName, collectionName string) *mongo.Collection {
 	return s.client.Database(dbName).Collection(collectionName)
 }
+
+// IsDocumentError сообщает, что запись отклонена из-за самих документов:
+// есть ошибки отдельных документов и нет ошибки write concern. Повтор такой
+// записи не поможет, в отличие от сбоев сети, таймаутов и недоступности сервера.
+func IsDocumentError(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	return errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil && len(bulkErr.WriteErrors) > 0
+}
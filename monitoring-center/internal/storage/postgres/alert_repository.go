@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+const alertColumns = `id, rule_id, rule_name, host_id, series, severity, state, trigger_value, last_value,
+        started_at, fired_at, acknowledged_at, acknowledged_by, resolved_at, resolved_by, comment, updated_at`
+
+func scanAlert(row pgx.Row, alert *models.Alert) error {
+	return row.Scan(
+		&alert.ID,
+		&alert.RuleID,
+		&alert.RuleName,
+		&alert.HostID,
+		&alert.Series,
+		&alert.Severity,
+		&alert.State,
+		&alert.TriggerValue,
+		&alert.LastValue,
+		&alert.StartedAt,
+		&alert.FiredAt,
+		&alert.AcknowledgedAt,
+		&alert.AcknowledgedBy,
+		&alert.ResolvedAt,
+		&alert.ResolvedBy,
+		&alert.Comment,
+		&alert.UpdatedAt,
+	)
+}
+
+// Репозиторий экземпляров оповещений - история того, что происходило с хостами
+type AlertRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAlertRepository(pool *pgxpool.Pool) *AlertRepository {
+	return &AlertRepository{pool: pool}
+}
+
+func (r *AlertRepository) Create(ctx context.Context, alert *models.Alert) error {
+	query := `
+        INSERT INTO alerts (id, rule_id, rule_name, host_id, series, severity, state, trigger_value, last_value,
+            started_at, fired_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    `
+
+	alert.ID = uuid.New()
+	alert.UpdatedAt = time.Now()
+
+	_, err := r.pool.Exec(ctx, query,
+		alert.ID,
+		alert.RuleID,
+		alert.RuleName,
+		alert.HostID,
+		alert.Series,
+		alert.Severity,
+		alert.State,
+		alert.TriggerValue,
+		alert.LastValue,
+		alert.StartedAt,
+		alert.FiredAt,
+		alert.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+
+	return nil
+}
+
+// Update сохраняет изменяемые поля оповещения
+func (r *AlertRepository) Update(ctx context.Context, alert *models.Alert) error {
+	query := `
+        UPDATE alerts
+        SET state = $1, trigger_value = $2, last_value = $3, fired_at = $4, acknowledged_at = $5,
+            acknowledged_by = $6, resolved_at = $7, resolved_by = $8, comment = $9, updated_at = $10
+        WHERE id = $11
+    `
+
+	alert.UpdatedAt = time.Now()
+
+	_, err := r.pool.Exec(ctx, query,
+		alert.State,
+		alert.TriggerValue,
+		alert.LastValue,
+		alert.FiredAt,
+		alert.AcknowledgedAt,
+		alert.AcknowledgedBy,
+		alert.ResolvedAt,
+		alert.ResolvedBy,
+		alert.Comment,
+		alert.UpdatedAt,
+		alert.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	return nil
+}
+
+// FindActive возвращает все неразрешенные оповещения
+func (r *AlertRepository) FindActive(ctx context.Context) ([]models.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE state <> $1`
+
+	rows, err := r.pool.Query(ctx, query, models.AlertResolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []models.Alert
+	for rows.Next() {
+		var alert models.Alert
+		if err := scanAlert(rows, &alert); err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+func (r *AlertRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Alert, error) {
+	query := `SELECT ` + alertColumns + ` FROM alerts WHERE id = $1`
+
+	var alert models.Alert
+	if err := scanAlert(r.pool.QueryRow(ctx, query, id), &alert); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find alert: %w", err)
+	}
+
+	return &alert, nil
+}
+
+// FindAll возвращает оповещения с пагинацией и фильтрацией
+func (r *AlertRepository) FindAll(ctx context.Context, query models.AlertsQuery) ([]models.Alert, int, error) {
+	baseQuery := `SELECT ` + alertColumns + ` FROM alerts WHERE 1=1`
+	countQuery := `SELECT COUNT(*) FROM alerts WHERE 1=1`
+
+	var params []any
+	var conditions []string
+
+	if query.HostID != "" {
+		conditions = append(conditions, fmt.Sprintf("host_id = $%d", len(params)+1))
+		params = append(params, query.HostID)
+	}
+
+	if query.RuleID != "" {
+		conditions = append(conditions, fmt.Sprintf("rule_id = $%d", len(params)+1))
+		params = append(params, query.RuleID)
+	}
+
+	if query.State != "" {
+		conditions = append(conditions, fmt.Sprintf("state = $%d", len(params)+1))
+		params = append(params, query.State)
+	}
+
+	if query.From != nil {
+		conditions = append(conditions, fmt.Sprintf("(resolved_at IS NULL OR resolved_at >= $%d)", len(params)+1))
+		params = append(params, *query.From)
+	}
+
+	if query.To != nil {
+		conditions = append(conditions, fmt.Sprintf("started_at <= $%d", len(params)+1))
+		params = append(params, *query.To)
+	}
+
+	if len(conditions) > 0 {
+		whereClause := " AND " + strings.Join(conditions, " AND ")
+		baseQuery += whereClause
+		countQuery += whereClause
+	}
+
+	baseQuery += " ORDER BY started_at DESC"
+	baseQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(params)+1, len(params)+2)
+
+	offset := (query.Page - 1) * query.Limit
+	params = append(params, query.Limit, offset)
+
+	var total int
+	err := r.pool.QueryRow(ctx, countQuery, params[:len(params)-2]...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count alerts: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, baseQuery, params...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []models.Alert{}
+	for rows.Next() {
+		var alert models.Alert
+		if err := scanAlert(rows, &alert); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return alerts, total, nil
+}
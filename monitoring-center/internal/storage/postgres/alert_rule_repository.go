@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+const alertRuleColumns = `id, name, description, metric_type, field, match, operator, threshold,
+        for_seconds, host_id, host_label, severity, enabled, created_at, updated_at`
+
+func scanAlertRule(row pgx.Row, rule *models.AlertRule) error {
+	var forSeconds int
+	err := row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Description,
+		&rule.MetricType,
+		&rule.Field,
+		&rule.Match,
+		&rule.Operator,
+		&rule.Threshold,
+		&forSeconds,
+		&rule.HostID,
+		&rule.HostLabel,
+		&rule.Severity,
+		&rule.Enabled,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	rule.For = models.Duration(time.Duration(forSeconds) * time.Second)
+	return err
+}
+
+type AlertRuleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAlertRuleRepository(pool *pgxpool.Pool) *AlertRuleRepository {
+	return &AlertRuleRepository{pool: pool}
+}
+
+func (r *AlertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	query := `
+        INSERT INTO alert_rules (id, name, description, metric_type, field, match, operator, threshold,
+            for_seconds, host_id, host_label, severity, enabled, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+    `
+
+	now := time.Now()
+	rule.ID = uuid.New()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	_, err := r.pool.Exec(ctx, query,
+		rule.ID,
+		rule.Name,
+		rule.Description,
+		rule.MetricType,
+		rule.Field,
+		rule.Match,
+		rule.Operator,
+		rule.Threshold,
+		int(time.Duration(rule.For)/time.Second),
+		rule.HostID,
+		rule.HostLabel,
+		rule.Severity,
+		rule.Enabled,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	return nil
+}
+
+// FindAll возвращает правила, при onlyEnabled - только включенные
+func (r *AlertRuleRepository) FindAll(ctx context.Context, onlyEnabled bool) ([]models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules`
+	if onlyEnabled {
+		query += ` WHERE enabled`
+	}
+	query += ` ORDER BY name`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.AlertRule{}
+	for rows.Next() {
+		var rule models.AlertRule
+		if err := scanAlertRule(rows, &rule); err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (r *AlertRuleRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE id = $1`
+
+	var rule models.AlertRule
+	if err := scanAlertRule(r.pool.QueryRow(ctx, query, id), &rule); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find alert rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+func (r *AlertRuleRepository) Update(ctx context.Context, rule *models.AlertRule) error {
+	query := `
+        UPDATE alert_rules
+        SET name = $1, description = $2, metric_type = $3, field = $4, match = $5, operator = $6,
+            threshold = $7, for_seconds = $8, host_id = $9, host_label = $10, severity = $11,
+            enabled = $12, updated_at = $13
+        WHERE id = $14
+    `
+
+	rule.UpdatedAt = time.Now()
+
+	_, err := r.pool.Exec(ctx, query,
+		rule.Name,
+		rule.Description,
+		rule.MetricType,
+		rule.Field,
+		rule.Match,
+		rule.Operator,
+		rule.Threshold,
+		int(time.Duration(rule.For)/time.Second),
+		rule.HostID,
+		rule.HostLabel,
+		rule.Severity,
+		rule.Enabled,
+		rule.UpdatedAt,
+		rule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AlertRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AlertRuleRepository) IsNameExistsExcluding(ctx context.Context, name string, excludeID uuid.UUID) (bool, error) {
+	query := `SELECT COUNT(*) FROM alert_rules WHERE name = $1 AND id != $2`
+
+	var count int
+	err := r.pool.QueryRow(ctx, query, name, excludeID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
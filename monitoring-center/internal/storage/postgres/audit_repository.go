@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+const auditColumns = `id, occurred_at, actor_id, actor_name, action, method, path,
+        target_type, target_id, changes, status, request_id, source_ip`
+
+func scanAuditEntry(row pgx.Row, entry *models.AuditEntry) error {
+	return row.Scan(
+		&entry.ID,
+		&entry.OccurredAt,
+		&entry.ActorID,
+		&entry.ActorName,
+		&entry.Action,
+		&entry.Method,
+		&entry.Path,
+		&entry.TargetType,
+		&entry.TargetID,
+		&entry.Changes,
+		&entry.Status,
+		&entry.RequestID,
+		&entry.SourceIP,
+	)
+}
+
+// Репозиторий журнала аудита. Записи только добавляются.
+type AuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+// Create добавляет запись в журнал
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+        INSERT INTO audit_log (actor_id, actor_name, action, method, path,
+            target_type, target_id, changes, status, request_id, source_ip)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id, occurred_at
+    `
+
+	if entry.Changes == nil {
+		entry.Changes = map[string]models.FieldChange{}
+	}
+
+	err := r.pool.QueryRow(ctx, query,
+		entry.ActorID,
+		entry.ActorName,
+		entry.Action,
+		entry.Method,
+		entry.Path,
+		entry.TargetType,
+		entry.TargetID,
+		entry.Changes,
+		entry.Status,
+		entry.RequestID,
+		entry.SourceIP,
+	).Scan(&entry.ID, &entry.OccurredAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// FindAll возвращает записи журнала с пагинацией и фильтрацией, новые первыми
+func (r *AuditRepository) FindAll(ctx context.Context, query models.AuditQuery) ([]models.AuditEntry, int, error) {
+	baseQuery := `SELECT ` + auditColumns + ` FROM audit_log WHERE 1=1`
+	countQuery := `SELECT COUNT(*) FROM audit_log WHERE 1=1`
+
+	var params []any
+	var conditions []string
+
+	filters := []struct {
+		column string
+		value  string
+	}{
+		{"actor_name", query.Actor},
+		{"action", query.Action},
+		{"method", query.Method},
+		{"target_type", query.TargetType},
+		{"target_id", query.TargetID},
+		{"request_id", query.RequestID},
+	}
+	for _, filter := range filters {
+		if filter.value != "" {
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", filter.column, len(params)+1))
+			params = append(params, filter.value)
+		}
+	}
+
+	if query.From != nil {
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", len(params)+1))
+		params = append(params, *query.From)
+	}
+
+	if query.To != nil {
+		conditions = append(conditions, fmt.Sprintf("occurred_at <= $%d", len(params)+1))
+		params = append(params, *query.To)
+	}
+
+	if len(conditions) > 0 {
+		whereClause := " AND " + strings.Join(conditions, " AND ")
+		baseQuery += whereClause
+		countQuery += whereClause
+	}
+
+	baseQuery += " ORDER BY occurred_at DESC, id DESC"
+	baseQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(params)+1, len(params)+2)
+
+	offset := (query.Page - 1) * query.Limit
+	params = append(params, query.Limit, offset)
+
+	var total int
+	err := r.pool.QueryRow(ctx, countQuery, params[:len(params)-2]...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, baseQuery, params...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+// Репозиторий ключей агентов и сведений о машинах хостов
+type CredentialRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCredentialRepository(pool *pgxpool.Pool) *CredentialRepository {
+	return &CredentialRepository{pool: pool}
+}
+
+// Enroll в одной транзакции создает хост, его первый ключ и сведения о машине
+func (r *CredentialRepository) Enroll(ctx context.Context, host *models.Host, inventory *models.HostInventory, secretHash string) (*models.HostCredential, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertHost(ctx, tx, host); err != nil {
+		return nil, err
+	}
+
+	credential, err := insertCredential(ctx, tx, host.ID, secretHash)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory.HostID = host.ID
+	inventory.EnrolledAt = host.CreatedAt
+	inventory.UpdatedAt = host.CreatedAt
+	if inventory.IPs == nil {
+		inventory.IPs = []string{}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO host_inventory (host_id, hostname, ips, hardware, enrolled_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		inventory.HostID, inventory.Hostname, inventory.IPs, inventory.Hardware, inventory.EnrolledAt, inventory.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save host inventory: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return credential, nil
+}
+
+// CreateHost в одной транзакции создает хост и его первый ключ
+func (r *CredentialRepository) CreateHost(ctx context.Context, host *models.Host, secretHash string) (*models.HostCredential, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertHost(ctx, tx, host); err != nil {
+		return nil, err
+	}
+
+	credential, err := insertCredential(ctx, tx, host.ID, secretHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return credential, nil
+}
+
+// Issue выдает хосту новый ключ. С revokeOthers остальные действующие ключи
+// хоста отзываются в той же транзакции. Возвращает число отозванных ключей.
+func (r *CredentialRepository) Issue(ctx context.Context, hostID uuid.UUID, secretHash string, revokeOthers bool) (*models.HostCredential, int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var revoked int64
+	if revokeOthers {
+		tag, err := tx.Exec(ctx,
+			`UPDATE host_credentials SET revoked_at = $2 WHERE host_id = $1 AND revoked_at IS NULL`,
+			hostID, time.Now())
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to revoke host credentials: %w", err)
+		}
+		revoked = tag.RowsAffected()
+	}
+
+	credential, err := insertCredential(ctx, tx, hostID, secretHash)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return credential, revoked, nil
+}
+
+// Revoke отзывает действующий ключ хоста. Возвращает false, если такого ключа нет.
+func (r *CredentialRepository) Revoke(ctx context.Context, hostID, credentialID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE host_credentials SET revoked_at = $3 WHERE id = $1 AND host_id = $2 AND revoked_at IS NULL`,
+		credentialID, hostID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke host credential: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// FindByHost возвращает ключи хоста, включая отозванные, от новых к старым
+func (r *CredentialRepository) FindByHost(ctx context.Context, hostID uuid.UUID) ([]models.HostCredential, error) {
+	query := `SELECT id, host_id, created_at, last_used_at, revoked_at FROM host_credentials
+              WHERE host_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find host credentials: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []models.HostCredential{}
+	for rows.Next() {
+		var credential models.HostCredential
+		if err := rows.Scan(
+			&credential.ID,
+			&credential.HostID,
+			&credential.CreatedAt,
+			&credential.LastUsedAt,
+			&credential.RevokedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan host credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate host credentials: %w", err)
+	}
+
+	return credentials, nil
+}
+
+// FindHostBySecret возвращает хост, которому принадлежит действующий ключ
+// с хешем secretHash, или nil, если такого ключа нет или он отозван
+func (r *CredentialRepository) FindHostBySecret(ctx context.Context, secretHash string) (*uuid.UUID, error) {
+	var hostID uuid.UUID
+	err := r.pool.QueryRow(ctx,
+		`SELECT host_id FROM host_credentials WHERE secret_hash = $1 AND revoked_at IS NULL`,
+		secretHash).Scan(&hostID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find host credential: %w", err)
+	}
+
+	return &hostID, nil
+}
+
+// TouchLastUsed записывает время последнего использования ключей одним запросом.
+// secretHashes и usedAt - параллельные списки.
+func (r *CredentialRepository) TouchLastUsed(ctx context.Context, secretHashes []string, usedAt []time.Time) error {
+	query := `
+        UPDATE host_credentials c SET last_used_at = u.used_at
+        FROM unnest($1::text[], $2::timestamptz[]) AS u(secret_hash, used_at)
+        WHERE c.secret_hash = u.secret_hash
+          AND (c.last_used_at IS NULL OR c.last_used_at < u.used_at)
+    `
+
+	if _, err := r.pool.Exec(ctx, query, secretHashes, usedAt); err != nil {
+		return fmt.Errorf("failed to update credential usage: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CredentialRepository) FindInventory(ctx context.Context, hostID uuid.UUID) (*models.HostInventory, error) {
+	query := `SELECT host_id, hostname, ips, hardware, enrolled_at, updated_at FROM host_inventory WHERE host_id = $1`
+
+	var inventory models.HostInventory
+	err := r.pool.QueryRow(ctx, query, hostID).Scan(
+		&inventory.HostID,
+		&inventory.Hostname,
+		&inventory.IPs,
+		&inventory.Hardware,
+		&inventory.EnrolledAt,
+		&inventory.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find host inventory: %w", err)
+	}
+
+	return &inventory, nil
+}
+
+func insertCredential(ctx context.Context, db execer, hostID uuid.UUID, secretHash string) (*models.HostCredential, error) {
+	credential := &models.HostCredential{
+		ID:        uuid.New(),
+		HostID:    hostID,
+		CreatedAt: time.Now(),
+	}
+
+	_, err := db.Exec(ctx,
+		`INSERT INTO host_credentials (id, host_id, secret_hash, created_at) VALUES ($1, $2, $3, $4)`,
+		credential.ID, credential.HostID, secretHash, credential.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host credential: %w", err)
+	}
+
+	return credential, nil
+}
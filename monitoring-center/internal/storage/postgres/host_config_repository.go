@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+// Репозиторий конфигурации мониторинга хостов: отслеживаемые процессы,
+// контейнеры и порты. Любое изменение увеличивает версию конфигурации,
+// по которой агент понимает, что пора перенастроить коллекторы.
+type HostConfigRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewHostConfigRepository(pool *pgxpool.Pool) *HostConfigRepository {
+	return &HostConfigRepository{pool: pool}
+}
+
+// Get возвращает конфигурацию хоста. Если конфигурация не задавалась, версия равна 0
+func (r *HostConfigRepository) Get(ctx context.Context, hostID uuid.UUID) (*models.HostConfig, error) {
+	config := &models.HostConfig{
+		HostID:     hostID,
+		Processes:  []string{},
+		Containers: []string{},
+		Ports:      []models.WatchedPort{},
+	}
+
+	var updatedAt time.Time
+	err := r.pool.QueryRow(ctx, `SELECT version, updated_at FROM host_configs WHERE host_id = $1`, hostID).
+		Scan(&config.Version, &updatedAt)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to get host config version: %w", err)
+	}
+	if err == nil {
+		config.UpdatedAt = &updatedAt
+	}
+
+	config.Processes, err = r.listNames(ctx, "host_watched_processes", hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Containers, err = r.listNames(ctx, "host_watched_containers", hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT port, protocol FROM host_watched_ports WHERE host_id = $1 ORDER BY port, protocol`, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched ports: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var port models.WatchedPort
+		if err := rows.Scan(&port.Port, &port.Protocol); err != nil {
+			return nil, fmt.Errorf("failed to scan watched port: %w", err)
+		}
+		config.Ports = append(config.Ports, port)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watched ports: %w", err)
+	}
+
+	return config, nil
+}
+
+// GetVersion возвращает только версию конфигурации, чтобы дешево отвечать на опрос агента
+func (r *HostConfigRepository) GetVersion(ctx context.Context, hostID uuid.UUID) (int64, error) {
+	var version int64
+	err := r.pool.QueryRow(ctx, `SELECT version FROM host_configs WHERE host_id = $1`, hostID).Scan(&version)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get host config version: %w", err)
+	}
+	return version, nil
+}
+
+// Replace полностью заменяет конфигурацию хоста
+func (r *HostConfigRepository) Replace(ctx context.Context, hostID uuid.UUID, req models.UpdateHostConfigRequest) error {
+	return r.mutate(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		for _, table := range []string{"host_watched_processes", "host_watched_containers", "host_watched_ports"} {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE host_id = $1`, table), hostID); err != nil {
+				return false, fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+
+		for _, name := range req.Processes {
+			if _, err := insertName(ctx, tx, "host_watched_processes", hostID, name); err != nil {
+				return false, err
+			}
+		}
+
+		for _, name := range req.Containers {
+			if _, err := insertName(ctx, tx, "host_watched_containers", hostID, name); err != nil {
+				return false, err
+			}
+		}
+
+		for _, port := range req.Ports {
+			if _, err := insertPort(ctx, tx, hostID, port); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	})
+}
+
+// AddProcess добавляет процесс в список наблюдения. Возвращает false, если он уже там
+func (r *HostConfigRepository) AddProcess(ctx context.Context, hostID uuid.UUID, name string) (bool, error) {
+	return r.mutateOne(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		return insertName(ctx, tx, "host_watched_processes", hostID, name)
+	})
+}
+
+// RemoveProcess удаляет процесс из списка наблюдения. Возвращает false, если его там не было
+func (r *HostConfigRepository) RemoveProcess(ctx context.Context, hostID uuid.UUID, name string) (bool, error) {
+	return r.mutateOne(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		return deleteName(ctx, tx, "host_watched_processes", hostID, name)
+	})
+}
+
+// AddContainer добавляет контейнер в список наблюдения. Возвращает false, если он уже там
+func (r *HostConfigRepository) AddContainer(ctx context.Context, hostID uuid.UUID, name string) (bool, error) {
+	return r.mutateOne(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		return insertName(ctx, tx, "host_watched_containers", hostID, name)
+	})
+}
+
+// RemoveContainer удаляет контейнер из списка наблюдения. Возвращает false, если его там не было
+func (r *HostConfigRepository) RemoveContainer(ctx context.Context, hostID uuid.UUID, name string) (bool, error) {
+	return r.mutateOne(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		return deleteName(ctx, tx, "host_watched_containers", hostID, name)
+	})
+}
+
+// AddPort добавляет порт в список наблюдения. Возвращает false, если он уже там
+func (r *HostConfigRepository) AddPort(ctx context.Context, hostID uuid.UUID, port models.WatchedPort) (bool, error) {
+	return r.mutateOne(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		return insertPort(ctx, tx, hostID, port)
+	})
+}
+
+// RemovePort удаляет порт из списка наблюдения. Возвращает false, если его там не было
+func (r *HostConfigRepository) RemovePort(ctx context.Context, hostID uuid.UUID, port models.WatchedPort) (bool, error) {
+	return r.mutateOne(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		tag, err := tx.Exec(ctx, `DELETE FROM host_watched_ports WHERE host_id = $1 AND port = $2 AND protocol = $3`,
+			hostID, port.Port, port.Protocol)
+		if err != nil {
+			return false, fmt.Errorf("failed to delete watched port: %w", err)
+		}
+		return tag.RowsAffected() > 0, nil
+	})
+}
+
+func (r *HostConfigRepository) mutateOne(ctx context.Context, hostID uuid.UUID, fn func(tx pgx.Tx) (bool, error)) (bool, error) {
+	var changed bool
+	err := r.mutate(ctx, hostID, func(tx pgx.Tx) (bool, error) {
+		var err error
+		changed, err = fn(tx)
+		return changed, err
+	})
+	return changed, err
+}
+
+// mutate выполняет изменение в транзакции и увеличивает версию, если что-то поменялось
+func (r *HostConfigRepository) mutate(ctx context.Context, hostID uuid.UUID, fn func(tx pgx.Tx) (bool, error)) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	changed, err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		query := `
+            INSERT INTO host_configs (host_id, version, updated_at)
+            VALUES ($1, 1, $2)
+            ON CONFLICT (host_id) DO UPDATE
+            SET version = host_configs.version + 1, updated_at = EXCLUDED.updated_at
+        `
+		if _, err := tx.Exec(ctx, query, hostID, time.Now()); err != nil {
+			return fmt.Errorf("failed to bump host config version: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit host config: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HostConfigRepository) listNames(ctx context.Context, table string, hostID uuid.UUID) ([]string, error) {
+	rows, err := r.pool.Query(ctx, fmt.Sprintf(`SELECT name FROM %s WHERE host_id = $1 ORDER BY name`, table), hostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s: %w", table, err)
+	}
+
+	return names, nil
+}
+
+func insertName(ctx context.Context, tx pgx.Tx, table string, hostID uuid.UUID, name string) (bool, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (host_id, name, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, table)
+	tag, err := tx.Exec(ctx, query, hostID, name, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to insert into %s: %w", table, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func deleteName(ctx context.Context, tx pgx.Tx, table string, hostID uuid.UUID, name string) (bool, error) {
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE host_id = $1 AND name = $2`, table), hostID, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete from %s: %w", table, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func insertPort(ctx context.Context, tx pgx.Tx, hostID uuid.UUID, port models.WatchedPort) (bool, error) {
+	query := `INSERT INTO host_watched_ports (host_id, port, protocol, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`
+	tag, err := tx.Exec(ctx, query, hostID, port.Port, port.Protocol, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to insert watched port: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
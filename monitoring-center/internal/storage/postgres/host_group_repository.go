@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+// Репозиторий групп хостов и их состава
+type HostGroupRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewHostGroupRepository(pool *pgxpool.Pool) *HostGroupRepository {
+	return &HostGroupRepository{pool: pool}
+}
+
+// Create создает группу вместе с пустой арендой мастера
+func (r *HostGroupRepository) Create(ctx context.Context, group *models.HostGroup) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	group.ID = uuid.New()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO host_groups (id, name, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`,
+		group.ID, group.Name, group.Description, group.CreatedAt, group.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create host group: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO master_lease (group_id) VALUES ($1)`, group.ID); err != nil {
+		return fmt.Errorf("failed to create master lease: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HostGroupRepository) FindAll(ctx context.Context) ([]models.HostGroup, error) {
+	query := `SELECT id, name, description, created_at, updated_at FROM host_groups ORDER BY name`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query host groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.HostGroup{}
+	for rows.Next() {
+		var group models.HostGroup
+		if err := rows.Scan(&group.ID, &group.Name, &group.Description, &group.CreatedAt, &group.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan host group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating host groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+func (r *HostGroupRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.HostGroup, error) {
+	query := `SELECT id, name, description, created_at, updated_at FROM host_groups WHERE id = $1`
+
+	var group models.HostGroup
+	err := r.pool.QueryRow(ctx, query, id).Scan(&group.ID, &group.Name, &group.Description, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find host group: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (r *HostGroupRepository) FindByName(ctx context.Context, name string) (*models.HostGroup, error) {
+	query := `SELECT id, name, description, created_at, updated_at FROM host_groups WHERE name = $1`
+
+	var group models.HostGroup
+	err := r.pool.QueryRow(ctx, query, name).Scan(&group.ID, &group.Name, &group.Description, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find host group: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (r *HostGroupRepository) Update(ctx context.Context, group *models.HostGroup) error {
+	group.UpdatedAt = time.Now()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE host_groups SET name = $1, description = $2, updated_at = $3 WHERE id = $4`,
+		group.Name, group.Description, group.UpdatedAt, group.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update host group: %w", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет группу вместе с арендой ее мастера. История смены мастера
+// остается для разбора инцидентов: master_history.group_id не ссылается на
+// host_groups, потому что группа по умолчанию не хранится в таблице.
+func (r *HostGroupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, query := range []string{
+		`DELETE FROM master_lease WHERE group_id = $1`,
+		`DELETE FROM host_groups WHERE id = $1`,
+	} {
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to delete host group: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *HostGroupRepository) IsNameExistsExcluding(ctx context.Context, name string, excludeID uuid.UUID) (bool, error) {
+	query := `SELECT COUNT(*) FROM host_groups WHERE name = $1 AND id != $2`
+
+	var count int
+	err := r.pool.QueryRow(ctx, query, name, excludeID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// AddMember добавляет хост в группу. Повторное добавление ничего не меняет.
+func (r *HostGroupRepository) AddMember(ctx context.Context, groupID, hostID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO host_group_members (group_id, host_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		groupID, hostID)
+	if err != nil {
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember исключает хост из группы. Возвращает false, если хост в нее не входил.
+func (r *HostGroupRepository) RemoveMember(ctx context.Context, groupID, hostID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM host_group_members WHERE group_id = $1 AND host_id = $2`,
+		groupID, hostID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove group member: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// FindMembers возвращает хосты группы по убыванию приоритета
+func (r *HostGroupRepository) FindMembers(ctx context.Context, groupID uuid.UUID) ([]models.Host, error) {
+	query := `
+        SELECT ` + prefixedHostColumns + `
+        FROM hosts h
+        JOIN host_group_members m ON m.host_id = h.id
+        WHERE m.group_id = $1
+        ORDER BY h.priority DESC, h.created_at ASC
+    `
+
+	rows, err := r.pool.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group members: %w", err)
+	}
+	defer rows.Close()
+
+	hosts := []models.Host{}
+	for rows.Next() {
+		var host models.Host
+		if err := scanHost(rows, &host); err != nil {
+			return nil, fmt.Errorf("failed to scan host: %w", err)
+		}
+		hosts = append(hosts, host)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group members: %w", err)
+	}
+
+	return hosts, nil
+}
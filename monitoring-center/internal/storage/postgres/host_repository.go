@@ -2,20 +2,49 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nekitmilk/monitoring-center/internal/models"
 	// "go.mongodb.org/mongo-driver/internal/uuid"
 )
 
+// ErrHostExists - хост с таким именем или IP уже есть
+var ErrHostExists = errors.New("host with this name or IP already exists")
+
+// Код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
 // Этот класс для работы с конкретной таблицей - Hosts
 // Это паттерн проектирования - репозиторий
 
+// Колонки, которые читаются при выборке хоста, в порядке scanHost
+const hostColumns = `id, name, ip, priority, status, labels, last_seen_at, status_changed_at, created_at, updated_at`
+
+// Те же колонки для запросов с JOIN, где таблица hosts имеет псевдоним h
+const prefixedHostColumns = `h.id, h.name, h.ip, h.priority, h.status, h.labels, h.last_seen_at, h.status_changed_at, h.created_at, h.updated_at`
+
+func scanHost(row pgx.Row, host *models.Host) error {
+	return row.Scan(
+		&host.ID,
+		&host.Name,
+		&host.IP,
+		&host.Priority,
+		&host.Status,
+		&host.Labels,
+		&host.LastSeenAt,
+		&host.StatusChangedAt,
+		&host.CreatedAt,
+		&host.UpdatedAt,
+	)
+}
+
 // Инкапсулируем доступ к БД
 type HostRepository struct {
 	pool *pgxpool.Pool
@@ -28,17 +57,34 @@ func NewHostRepository(pool *pgxpool.Pool) *HostRepository {
 
 // Метод, который добавляет нового хоста в БД
 func (r *HostRepository) Create(ctx context.Context, host *models.Host) error {
-	query := `INSERT INTO hosts (id, name, ip, priority, status, created_at, updated_at) 
-              VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	return insertHost(ctx, r.pool, host)
+}
+
+// execer - общее у пула и транзакции, чтобы хост можно было создать в транзакции
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func insertHost(ctx context.Context, db execer, host *models.Host) error {
+	query := `INSERT INTO hosts (id, name, ip, priority, status, labels, status_changed_at, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	now := time.Now()
 	host.ID = uuid.New()
 	host.CreatedAt = now
 	host.UpdatedAt = now
 	host.Status = models.StatusUnknown
+	host.StatusChangedAt = &now
+	if host.Labels == nil {
+		host.Labels = []string{}
+	}
 
-	_, err := r.pool.Exec(ctx, query, host.ID, host.Name, host.IP, host.Priority, host.Status, host.CreatedAt, host.UpdatedAt)
+	_, err := db.Exec(ctx, query, host.ID, host.Name, host.IP, host.Priority, host.Status, host.Labels, host.StatusChangedAt, host.CreatedAt, host.UpdatedAt)
 
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("failed to create host: %w", ErrHostExists)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create host: %w", err)
 	}
@@ -74,7 +120,7 @@ func (r *HostRepository) IsIPExists(ctx context.Context, ip string) (bool, error
 func (r *HostRepository) FindAll(ctx context.Context, query models.HostsQuery) ([]models.Host, int, error) {
 	// Базовый запрос
 	baseQuery := `
-        SELECT id, name, ip, priority, status, created_at, updated_at 
+        SELECT ` + hostColumns + `
         FROM hosts 
         WHERE 1=1
     `
@@ -103,6 +149,11 @@ func (r *HostRepository) FindAll(ctx context.Context, query models.HostsQuery) (
 		params = append(params, "%"+query.Search+"%")
 	}
 
+	if query.Label != "" {
+		conditions = append(conditions, fmt.Sprintf("$%d = ANY(labels)", len(params)+1))
+		params = append(params, query.Label)
+	}
+
 	// Добавляем условия к запросам
 	if len(conditions) > 0 {
 		whereClause := " AND " + strings.Join(conditions, " AND ")
@@ -135,16 +186,7 @@ func (r *HostRepository) FindAll(ctx context.Context, query models.HostsQuery) (
 	var hosts []models.Host
 	for rows.Next() {
 		var host models.Host
-		err := rows.Scan(
-			&host.ID,
-			&host.Name,
-			&host.IP,
-			&host.Priority,
-			&host.Status,
-			&host.CreatedAt,
-			&host.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanHost(rows, &host); err != nil {
 			return nil, 0, fmt.Errorf("failed to scan host: %w", err)
 		}
 		hosts = append(hosts, host)
@@ -160,21 +202,13 @@ func (r *HostRepository) FindAll(ctx context.Context, query models.HostsQuery) (
 // FindByID возвращает хост по ID
 func (r *HostRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Host, error) {
 	query := `
-        SELECT id, name, ip, priority, status, created_at, updated_at 
+        SELECT ` + hostColumns + `
         FROM hosts 
         WHERE id = $1
     `
 
 	var host models.Host
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&host.ID,
-		&host.Name,
-		&host.IP,
-		&host.Priority,
-		&host.Status,
-		&host.CreatedAt,
-		&host.UpdatedAt,
-	)
+	err := scanHost(r.pool.QueryRow(ctx, query, id), &host)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -186,15 +220,18 @@ func (r *HostRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Ho
 	return &host, nil
 }
 
-// Update обновляет данные хоста
+// Update обновляет данные хоста. Статус не трогается: им управляет liveness-трекер
 func (r *HostRepository) Update(ctx context.Context, host *models.Host) error {
 	query := `
         UPDATE hosts 
-        SET name = $1, ip = $2, priority = $3, status = $4, updated_at = $5 
+        SET name = $1, ip = $2, priority = $3, labels = $4, updated_at = $5 
         WHERE id = $6
     `
 
 	host.UpdatedAt = time.Now()
+	if host.Labels == nil {
+		host.Labels = []string{}
+	}
 
 	_, err := r.pool.Exec(
 		ctx,
@@ -202,7 +239,7 @@ func (r *HostRepository) Update(ctx context.Context, host *models.Host) error {
 		host.Name,
 		host.IP,
 		host.Priority,
-		host.Status,
+		host.Labels,
 		host.UpdatedAt,
 		host.ID,
 	)
@@ -252,33 +289,207 @@ func (r *HostRepository) IsIPExistsExcluding(ctx context.Context, ip string, exc
 	return count > 0, nil
 }
 
-// FindMasterHost возвращает мастер-хост (хост с наивысшим приоритетом среди онлайн хостов)
-func (r *HostRepository) FindMasterHost(ctx context.Context) (*models.Host, error) {
-	query := `
-        SELECT id, name, ip, priority, status, created_at, updated_at 
-        FROM hosts 
-        WHERE status = $1 
-        ORDER BY priority DESC, created_at ASC 
-        LIMIT 1
-    `
+// FindByLabel возвращает все хосты с меткой label, а при пустой метке - все хосты
+func (r *HostRepository) FindByLabel(ctx context.Context, label string) ([]models.Host, error) {
+	query := `SELECT ` + hostColumns + ` FROM hosts`
+	var params []any
+	if label != "" {
+		query += ` WHERE $1 = ANY(labels)`
+		params = append(params, label)
+	}
+	query += ` ORDER BY name`
+
+	rows, err := r.pool.Query(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hosts by label: %w", err)
+	}
+	defer rows.Close()
+
+	var hosts []models.Host
+	for rows.Next() {
+		var host models.Host
+		if err := scanHost(rows, &host); err != nil {
+			return nil, fmt.Errorf("failed to scan host: %w", err)
+		}
+		hosts = append(hosts, host)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hosts: %w", err)
+	}
+
+	return hosts, nil
+}
+
+// FindMasterCandidate возвращает кандидата в мастера группы (хост с наивысшим приоритетом
+// среди онлайн хостов группы). В группе по умолчанию участвуют все хосты.
+// Текущий мастер хранится в аренде, см. MasterRepository.
+func (r *HostRepository) FindMasterCandidate(ctx context.Context, groupID uuid.UUID) (*models.Host, error) {
+	query := `SELECT ` + prefixedHostColumns + ` FROM hosts h WHERE h.status = $1`
+	params := []any{models.StatusOnline}
+
+	if groupID != models.DefaultGroupID {
+		query += ` AND EXISTS (SELECT 1 FROM host_group_members m WHERE m.group_id = $2 AND m.host_id = h.id)`
+		params = append(params, groupID)
+	}
+
+	query += ` ORDER BY h.priority DESC, h.created_at ASC LIMIT 1`
 
 	var host models.Host
-	err := r.pool.QueryRow(ctx, query, models.StatusOnline).Scan(
-		&host.ID,
-		&host.Name,
-		&host.IP,
-		&host.Priority,
-		&host.Status,
-		&host.CreatedAt,
-		&host.UpdatedAt,
-	)
+	err := scanHost(r.pool.QueryRow(ctx, query, params...), &host)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to find master host: %w", err)
+		return nil, fmt.Errorf("failed to find master candidate: %w", err)
+	}
+
+	return &host, nil
+}
+
+// FindGroupHost возвращает хост, если он входит в группу, иначе nil
+func (r *HostRepository) FindGroupHost(ctx context.Context, groupID, id uuid.UUID) (*models.Host, error) {
+	if groupID == models.DefaultGroupID {
+		return r.FindByID(ctx, id)
+	}
+
+	query := `
+        SELECT ` + prefixedHostColumns + `
+        FROM hosts h
+        JOIN host_group_members m ON m.host_id = h.id
+        WHERE m.group_id = $1 AND h.id = $2
+    `
+
+	var host models.Host
+	if err := scanHost(r.pool.QueryRow(ctx, query, groupID, id), &host); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find group host: %w", err)
 	}
 
 	return &host, nil
 }
+
+// MarkSeenBatch записывает время последнего приема метрик от хостов одним
+// запросом и переводит их в online. ids и seenAt - параллельные списки.
+// Возвращает совершенные переходы статуса. Удаленные хосты пропускаются.
+func (r *HostRepository) MarkSeenBatch(ctx context.Context, ids []uuid.UUID, seenAt []time.Time) ([]models.HostStatusChange, error) {
+	query := `
+        WITH seen AS (
+            SELECT * FROM unnest($1::uuid[], $2::timestamptz[]) AS s(id, seen_at)
+        ), locked AS (
+            SELECT h.id, h.status FROM hosts h
+            JOIN seen ON seen.id = h.id
+            FOR UPDATE OF h
+        ), updated AS (
+            UPDATE hosts h SET
+                last_seen_at = GREATEST(h.last_seen_at, seen.seen_at),
+                status = $3,
+                status_changed_at = CASE WHEN locked.status <> $3 THEN seen.seen_at ELSE h.status_changed_at END
+            FROM seen
+            JOIN locked ON locked.id = seen.id
+            WHERE h.id = seen.id
+            RETURNING h.id, locked.status AS old_status, seen.seen_at
+        )
+        INSERT INTO host_status_history (host_id, old_status, new_status, changed_at)
+        SELECT id, old_status, $3, seen_at FROM updated
+        WHERE old_status <> $3
+        RETURNING host_id, old_status, new_status, changed_at
+    `
+
+	rows, err := r.pool.Query(ctx, query, ids, seenAt, models.StatusOnline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark hosts seen: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.HostStatusChange
+	for rows.Next() {
+		var change models.HostStatusChange
+		if err := rows.Scan(&change.HostID, &change.OldStatus, &change.NewStatus, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// MarkOfflineNotSeenSince переводит в offline все хосты, от которых не было
+// метрик с момента cutoff, и возвращает совершенные переходы
+func (r *HostRepository) MarkOfflineNotSeenSince(ctx context.Context, cutoff, changedAt time.Time) ([]models.HostStatusChange, error) {
+	query := `
+        WITH stale AS (
+            SELECT id, status FROM hosts
+            WHERE status <> $1 AND COALESCE(last_seen_at, created_at) < $2
+            FOR UPDATE
+        ), updated AS (
+            UPDATE hosts h SET status = $1, status_changed_at = $3
+            FROM stale
+            WHERE h.id = stale.id
+            RETURNING h.id, stale.status AS old_status
+        )
+        INSERT INTO host_status_history (host_id, old_status, new_status, changed_at)
+        SELECT id, old_status, $1, $3 FROM updated
+        RETURNING host_id, old_status, new_status, changed_at
+    `
+
+	rows, err := r.pool.Query(ctx, query, models.StatusOffline, cutoff, changedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark stale hosts offline: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.HostStatusChange
+	for rows.Next() {
+		var change models.HostStatusChange
+		if err := rows.Scan(&change.HostID, &change.OldStatus, &change.NewStatus, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// FindStatusHistory возвращает историю переходов статуса хоста, новые первыми
+func (r *HostRepository) FindStatusHistory(ctx context.Context, id uuid.UUID, limit int) ([]models.HostStatusChange, error) {
+	query := `
+        SELECT host_id, old_status, new_status, changed_at
+        FROM host_status_history
+        WHERE host_id = $1
+        ORDER BY changed_at DESC, id DESC
+        LIMIT $2
+    `
+
+	rows, err := r.pool.Query(ctx, query, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.HostStatusChange{}
+	for rows.Next() {
+		var change models.HostStatusChange
+		if err := rows.Scan(&change.HostID, &change.OldStatus, &change.NewStatus, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history: %w", err)
+	}
+
+	return history, nil
+}
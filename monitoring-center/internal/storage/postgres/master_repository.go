@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+// Репозиторий аренды мастера и истории его смены
+type MasterRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMasterRepository(pool *pgxpool.Pool) *MasterRepository {
+	return &MasterRepository{pool: pool}
+}
+
+// GetLease возвращает аренду мастера группы или nil, если группы нет
+func (r *MasterRepository) GetLease(ctx context.Context, groupID uuid.UUID) (*models.MasterLease, error) {
+	query := `SELECT group_id, host_id, term, acquired_at, expires_at FROM master_lease WHERE group_id = $1`
+
+	var lease models.MasterLease
+	err := r.pool.QueryRow(ctx, query, groupID).Scan(&lease.GroupID, &lease.HostID, &lease.Term, &lease.AcquiredAt, &lease.ExpiresAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get master lease: %w", err)
+	}
+
+	return &lease, nil
+}
+
+// Renew продлевает аренду текущего мастера. Возвращает false, если term
+// изменился с момента чтения аренды или аренда уже истекла к моменту now:
+// истекшую аренду можно только передать через Change с новым term.
+func (r *MasterRepository) Renew(ctx context.Context, groupID uuid.UUID, term int64, now, expiresAt time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE master_lease SET expires_at = $1 WHERE group_id = $2 AND term = $3 AND (expires_at IS NULL OR expires_at >= $4)`,
+		expiresAt, groupID, term, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew master lease: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// Change передает аренду группы новому мастеру (nil - мастера нет), увеличивая term,
+// и записывает смену в историю. Изменение проходит, только если term
+// все еще равен expectedTerm, иначе возвращается nil.
+func (r *MasterRepository) Change(ctx context.Context, groupID uuid.UUID, expectedTerm int64, newHostID *uuid.UUID, reason string, now, expiresAt time.Time) (*models.MasterChange, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE master_lease lease
+        SET host_id = $1, term = prev.term + 1, acquired_at = $2, expires_at = $3
+        FROM master_lease prev
+        WHERE lease.group_id = $4 AND prev.group_id = $4 AND prev.term = $5
+        RETURNING lease.term, prev.host_id
+    `
+
+	change := models.MasterChange{
+		GroupID:   groupID,
+		NewHostID: newHostID,
+		Reason:    reason,
+		ChangedAt: now,
+	}
+
+	var acquiredAt, leaseExpiresAt *time.Time
+	if newHostID != nil {
+		acquiredAt, leaseExpiresAt = &now, &expiresAt
+	}
+
+	err = tx.QueryRow(ctx, query, newHostID, acquiredAt, leaseExpiresAt, groupID, expectedTerm).Scan(&change.Term, &change.OldHostID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to change master: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO master_history (group_id, term, old_host_id, new_host_id, reason, changed_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		change.GroupID, change.Term, change.OldHostID, change.NewHostID, change.Reason, change.ChangedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record master change: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &change, nil
+}
+
+// FindHistory возвращает историю смены мастера группы, новые записи первыми
+func (r *MasterRepository) FindHistory(ctx context.Context, groupID uuid.UUID, limit int) ([]models.MasterChange, error) {
+	query := `
+        SELECT group_id, term, old_host_id, new_host_id, reason, changed_at
+        FROM master_history
+        WHERE group_id = $1
+        ORDER BY changed_at DESC, id DESC
+        LIMIT $2
+    `
+
+	rows, err := r.pool.Query(ctx, query, groupID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query master history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.MasterChange{}
+	for rows.Next() {
+		var change models.MasterChange
+		if err := rows.Scan(&change.GroupID, &change.Term, &change.OldHostID, &change.NewHostID, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan master change: %w", err)
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating master history: %w", err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+const notificationChannelColumns = `id, name, type, config, events, min_severity, enabled, created_at, updated_at`
+
+func scanNotificationChannel(row pgx.Row, channel *models.NotificationChannel) error {
+	return row.Scan(
+		&channel.ID,
+		&channel.Name,
+		&channel.Type,
+		&channel.Config,
+		&channel.Events,
+		&channel.MinSeverity,
+		&channel.Enabled,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+}
+
+// Репозиторий каналов уведомлений и журнала доставки
+type NotificationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewNotificationRepository(pool *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{pool: pool}
+}
+
+func (r *NotificationRepository) CreateChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	query := `
+        INSERT INTO notification_channels (id, name, type, config, events, min_severity, enabled, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+
+	now := time.Now()
+	channel.ID = uuid.New()
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+
+	_, err := r.pool.Exec(ctx, query,
+		channel.ID,
+		channel.Name,
+		channel.Type,
+		channel.Config,
+		channel.Events,
+		channel.MinSeverity,
+		channel.Enabled,
+		channel.CreatedAt,
+		channel.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// FindChannels возвращает каналы, при onlyEnabled - только включенные
+func (r *NotificationRepository) FindChannels(ctx context.Context, onlyEnabled bool) ([]models.NotificationChannel, error) {
+	query := `SELECT ` + notificationChannelColumns + ` FROM notification_channels`
+	if onlyEnabled {
+		query += ` WHERE enabled`
+	}
+	query += ` ORDER BY name`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	channels := []models.NotificationChannel{}
+	for rows.Next() {
+		var channel models.NotificationChannel
+		if err := scanNotificationChannel(rows, &channel); err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification channels: %w", err)
+	}
+
+	return channels, nil
+}
+
+func (r *NotificationRepository) FindChannelByID(ctx context.Context, id uuid.UUID) (*models.NotificationChannel, error) {
+	query := `SELECT ` + notificationChannelColumns + ` FROM notification_channels WHERE id = $1`
+
+	var channel models.NotificationChannel
+	if err := scanNotificationChannel(r.pool.QueryRow(ctx, query, id), &channel); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find notification channel: %w", err)
+	}
+
+	return &channel, nil
+}
+
+func (r *NotificationRepository) UpdateChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	query := `
+        UPDATE notification_channels
+        SET name = $1, type = $2, config = $3, events = $4, min_severity = $5, enabled = $6, updated_at = $7
+        WHERE id = $8
+    `
+
+	channel.UpdatedAt = time.Now()
+
+	_, err := r.pool.Exec(ctx, query,
+		channel.Name,
+		channel.Type,
+		channel.Config,
+		channel.Events,
+		channel.MinSeverity,
+		channel.Enabled,
+		channel.UpdatedAt,
+		channel.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update notification channel: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) DeleteChannel(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM notification_channels WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) IsChannelNameExistsExcluding(ctx context.Context, name string, excludeID uuid.UUID) (bool, error) {
+	query := `SELECT COUNT(*) FROM notification_channels WHERE name = $1 AND id != $2`
+
+	var count int
+	err := r.pool.QueryRow(ctx, query, name, excludeID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// LogDelivery записывает результат доставки уведомления в канал
+func (r *NotificationRepository) LogDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	query := `
+        INSERT INTO notification_deliveries (channel_id, event, subject, status, attempts, error, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+
+	err := r.pool.QueryRow(ctx, query,
+		delivery.ChannelID,
+		delivery.Event,
+		delivery.Subject,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.Error,
+		delivery.CreatedAt,
+	).Scan(&delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to log notification delivery: %w", err)
+	}
+
+	return nil
+}
+
+// FindDeliveries возвращает журнал доставки канала, новые записи первыми
+func (r *NotificationRepository) FindDeliveries(ctx context.Context, channelID uuid.UUID, limit int) ([]models.NotificationDelivery, error) {
+	query := `
+        SELECT id, channel_id, event, subject, status, attempts, error, created_at
+        FROM notification_deliveries
+        WHERE channel_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2
+    `
+
+	rows, err := r.pool.Query(ctx, query, channelID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.NotificationDelivery{}
+	for rows.Next() {
+		var d models.NotificationDelivery
+		if err := rows.Scan(&d.ID, &d.ChannelID, &d.Event, &d.Subject, &d.Status, &d.Attempts, &d.Error, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
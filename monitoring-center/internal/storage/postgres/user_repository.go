@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+// Репозиторий пользователей API и их токенов
+type UserRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
+	return &UserRepository{pool: pool}
+}
+
+const userColumns = `id, username, password_hash, role, disabled, created_at, updated_at`
+
+func scanUser(row pgx.Row) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Role,
+		&user.Disabled,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	query := `INSERT INTO users (id, username, password_hash, role, disabled, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	now := time.Now()
+	user.ID = uuid.New()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	_, err := r.pool.Exec(ctx, query, user.ID, user.Username, user.PasswordHash, user.Role, user.Disabled, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// CreateFirst создает пользователя, только если в БД еще нет ни одного.
+// Возвращает false, если пользователи уже есть.
+func (r *UserRepository) CreateFirst(ctx context.Context, user *models.User) (bool, error) {
+	query := `INSERT INTO users (id, username, password_hash, role, disabled, created_at, updated_at)
+              SELECT $1, $2, $3, $4, $5, $6, $7
+              WHERE NOT EXISTS (SELECT 1 FROM users)`
+
+	now := time.Now()
+	user.ID = uuid.New()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	tag, err := r.pool.Exec(ctx, query, user.ID, user.Username, user.PasswordHash, user.Role, user.Disabled, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create first user: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *UserRepository) IsUsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check username existence: %w", err)
+	}
+	return exists, nil
+}
+
+// Count возвращает число пользователей
+func (r *UserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// CountActiveAdmins возвращает число включенных администраторов, кроме excludeID
+func (r *UserRepository) CountActiveAdmins(ctx context.Context, excludeID uuid.UUID) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM users WHERE role = $1 AND NOT disabled AND id <> $2`,
+		models.UserRoleAdmin, excludeID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count admins: %w", err)
+	}
+	return count, nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]models.User, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+userColumns+` FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, err := scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	user, err := scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return user, nil
+}
+
+// Update сохраняет изменения пользователя. С revokeTokens в той же транзакции
+// отзываются все его токены (смена пароля, блокировка). Возвращает число отозванных.
+func (r *UserRepository) Update(ctx context.Context, user *models.User, revokeTokens bool) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	user.UpdatedAt = time.Now()
+	_, err = tx.Exec(ctx,
+		`UPDATE users SET password_hash = $2, role = $3, disabled = $4, updated_at = $5 WHERE id = $1`,
+		user.ID, user.PasswordHash, user.Role, user.Disabled, user.UpdatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	var revoked int64
+	if revokeTokens {
+		tag, err := tx.Exec(ctx,
+			`UPDATE api_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`,
+			user.ID, user.UpdatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to revoke API tokens: %w", err)
+		}
+		revoked = tag.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit user update: %w", err)
+	}
+
+	return revoked, nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// CreateToken сохраняет токен пользователя по хешу
+func (r *UserRepository) CreateToken(ctx context.Context, token *models.APIToken, tokenHash string) error {
+	query := `INSERT INTO api_tokens (id, user_id, name, token_hash, created_at, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6)`
+
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+
+	_, err := r.pool.Exec(ctx, query, token.ID, token.UserID, token.Name, tokenHash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return nil
+}
+
+// FindTokens возвращает токены пользователя, включая отозванные и истекшие
+func (r *UserRepository) FindTokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	query := `SELECT id, user_id, name, created_at, last_used_at, expires_at, revoked_at
+              FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		var token models.APIToken
+		if err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.CreatedAt,
+			&token.LastUsedAt,
+			&token.ExpiresAt,
+			&token.RevokedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken отзывает действующий токен пользователя. Возвращает false, если такого токена нет.
+func (r *UserRepository) RevokeToken(ctx context.Context, userID, tokenID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE api_tokens SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		tokenID, userID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Authenticate находит включенного пользователя по хешу действующего токена
+// и отмечает время использования токена. Если токен не подходит, возвращает nil.
+func (r *UserRepository) Authenticate(ctx context.Context, tokenHash string) (*models.User, error) {
+	query := `UPDATE api_tokens t SET last_used_at = $2
+              FROM users u
+              WHERE t.token_hash = $1 AND u.id = t.user_id AND NOT u.disabled
+                AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > $2)
+              RETURNING u.id, u.username, u.password_hash, u.role, u.disabled, u.created_at, u.updated_at`
+
+	user, err := scanUser(r.pool.QueryRow(ctx, query, tokenHash, time.Now()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to authenticate API token: %w", err)
+	}
+	return user, nil
+}
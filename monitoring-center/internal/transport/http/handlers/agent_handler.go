@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type AgentHandler struct {
+	credentialRepo  *postgres.CredentialRepository
+	bootstrapTokens []string
+}
+
+func NewAgentHandler(credentialRepo *postgres.CredentialRepository, bootstrapTokens []string) *AgentHandler {
+	return &AgentHandler{
+		credentialRepo:  credentialRepo,
+		bootstrapTokens: bootstrapTokens,
+	}
+}
+
+// Enroll регистрирует хост по bootstrap-токену и выдает агенту ключ
+// @Summary Enroll agent
+// @Description Create a host for a new agent using a bootstrap token. The first IP becomes the host IP. The returned secret is shown only once.
+// @Tags agents
+// @Accept json
+// @Produce json
+// @Param request body models.EnrollRequest true "Agent hostname, IPs and hardware"
+// @Success 201 {object} models.EnrollResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Enrollment is disabled"
+// @Failure 409 {object} map[string]string "Host with this name or IP already exists"
+// @Failure 500 {object} map[string]string
+// @Router /api/agents/enroll [post]
+func (h *AgentHandler) Enroll(c *gin.Context) {
+	if len(h.bootstrapTokens) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Agent enrollment is disabled",
+		})
+		return
+	}
+
+	var req models.EnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !auth.TokenAllowed(req.Token, h.bootstrapTokens) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid bootstrap token",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	ip := req.IPs[0]
+
+	secret, secretHash, err := auth.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue agent credential",
+		})
+		return
+	}
+
+	priority := req.Priority
+	if priority == 0 {
+		priority = 1
+	}
+
+	host := &models.Host{
+		Name:     req.Hostname,
+		IP:       ip,
+		Priority: priority,
+		Labels:   req.Labels,
+	}
+	inventory := &models.HostInventory{
+		Hostname: req.Hostname,
+		IPs:      req.IPs,
+		Hardware: req.Hardware,
+	}
+
+	// Занятые имя и IP определяет база: агенты могут регистрироваться одновременно
+	credential, err := h.credentialRepo.Enroll(ctx, host, inventory, secretHash)
+	if errors.Is(err, postgres.ErrHostExists) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Host with this name or IP already exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to enroll agent",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "hosts", host.ID.String(), nil, host)
+	c.JSON(http.StatusCreated, models.EnrollResponse{
+		HostID:       host.ID,
+		CredentialID: credential.ID,
+		Secret:       secret,
+	})
+}
+
+// GetHostInventory возвращает сведения о машине, переданные агентом при регистрации
+// @Summary Get host inventory
+// @Description Get hostname, IPs and hardware reported by the host agent on enrollment
+// @Tags hosts
+// @Produce json
+// @Param id path string true "Host ID"
+// @Success 200 {object} models.HostInventory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/inventory [get]
+func (h *AgentHandler) GetHostInventory(c *gin.Context) {
+	hostID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid host ID format",
+		})
+		return
+	}
+
+	inventory, err := h.credentialRepo.FindInventory(c.Request.Context(), hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch host inventory",
+			"details": err.Error(),
+		})
+		return
+	}
+	if inventory == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host inventory not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
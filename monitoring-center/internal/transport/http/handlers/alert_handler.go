@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/service/alerting"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type AlertHandler struct {
+	alertRepo *postgres.AlertRepository
+	engine    *alerting.Engine
+}
+
+func NewAlertHandler(alertRepo *postgres.AlertRepository, engine *alerting.Engine) *AlertHandler {
+	return &AlertHandler{
+		alertRepo: alertRepo,
+		engine:    engine,
+	}
+}
+
+// GetAlerts возвращает историю оповещений с пагинацией и фильтрацией
+// @Summary Get alerts
+// @Description Get alert instances filtered by host, rule, state and time range
+// @Tags alerts
+// @Produce json
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Number of items per page" default(20) minimum(1) maximum(100)
+// @Param host_id query string false "Filter by host ID"
+// @Param rule_id query string false "Filter by alert rule ID"
+// @Param state query string false "Filter by state" Enums(pending, firing, acknowledged, resolved)
+// @Param from query string false "Active at or after (RFC3339)"
+// @Param to query string false "Started at or before (RFC3339)"
+// @Success 200 {object} models.AlertsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts [get]
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	var query models.AlertsQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	alerts, total, err := h.alertRepo.FindAll(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch alerts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
+
+	c.JSON(http.StatusOK, models.AlertsResponse{
+		Alerts:      alerts,
+		Total:       total,
+		Page:        query.Page,
+		Limit:       query.Limit,
+		TotalPages:  totalPages,
+		HasNext:     query.Page < totalPages,
+		HasPrevious: query.Page > 1,
+	})
+}
+
+// GetAlertByID возвращает оповещение
+// @Summary Get alert by ID
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Alert ID"
+// @Success 200 {object} models.Alert
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts/{id} [get]
+func (h *AlertHandler) GetAlertByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid alert ID format",
+		})
+		return
+	}
+
+	alert, err := h.alertRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch alert",
+		})
+		return
+	}
+	if alert == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Alert not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// AcknowledgeAlert подтверждает оповещение
+// @Summary Acknowledge alert
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert ID"
+// @Param request body models.AlertActionRequest false "Acknowledgement comment"
+// @Success 200 {object} models.Alert
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts/{id}/ack [post]
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	h.changeState(c, h.engine.Acknowledge)
+}
+
+// ResolveAlert вручную разрешает оповещение
+// @Summary Resolve alert
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert ID"
+// @Param request body models.AlertActionRequest false "Resolution comment"
+// @Success 200 {object} models.Alert
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alerts/{id}/resolve [post]
+func (h *AlertHandler) ResolveAlert(c *gin.Context) {
+	h.changeState(c, h.engine.Resolve)
+}
+
+func (h *AlertHandler) changeState(c *gin.Context, change func(ctx context.Context, id uuid.UUID, by, comment string) (*models.Alert, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid alert ID format",
+		})
+		return
+	}
+
+	user := middleware.CurrentUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	// Тело необязательно
+	var req models.AlertActionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid input data",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	// Состояние до изменения для журнала аудита
+	before, err := h.alertRepo.FindByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch alert",
+		})
+		return
+	}
+
+	// Исполнитель - только аутентифицированный пользователь, не поле запроса
+	alert, err := change(ctx, id, user.Username, req.Comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, alerting.ErrAlertNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Alert not found",
+			})
+		case errors.Is(err, alerting.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Alert cannot be moved to this state",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update alert",
+			})
+		}
+		return
+	}
+
+	middleware.AuditChange(c, "alerts", id.String(), before, alert)
+	c.JSON(http.StatusOK, alert)
+}
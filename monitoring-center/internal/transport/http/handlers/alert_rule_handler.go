@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/service/alerting"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type AlertRuleHandler struct {
+	ruleRepo *postgres.AlertRuleRepository
+	hostRepo *postgres.HostRepository
+	engine   *alerting.Engine
+}
+
+func NewAlertRuleHandler(ruleRepo *postgres.AlertRuleRepository, hostRepo *postgres.HostRepository, engine *alerting.Engine) *AlertRuleHandler {
+	return &AlertRuleHandler{
+		ruleRepo: ruleRepo,
+		hostRepo: hostRepo,
+		engine:   engine,
+	}
+}
+
+// GetAlertRules возвращает все правила оповещений
+// @Summary Get alert rules
+// @Tags alert-rules
+// @Produce json
+// @Param enabled query bool false "Only enabled rules"
+// @Success 200 {array} models.AlertRule
+// @Failure 500 {object} map[string]string
+// @Router /api/alert-rules [get]
+func (h *AlertRuleHandler) GetAlertRules(c *gin.Context) {
+	rules, err := h.ruleRepo.FindAll(c.Request.Context(), c.Query("enabled") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch alert rules",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateAlertRule создает правило оповещения
+// @Summary Create alert rule
+// @Description Create a threshold rule, e.g. cpu > 90 for 5m on hosts labelled db
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param request body models.AlertRuleRequest true "Alert rule"
+// @Success 201 {object} models.AlertRule
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alert-rules [post]
+func (h *AlertRuleHandler) CreateAlertRule(c *gin.Context) {
+	req, ok := h.bindRule(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if exists, err := h.ruleRepo.IsNameExistsExcluding(ctx, req.Name, uuid.Nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check alert rule name",
+		})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Alert rule with this name already exists",
+		})
+		return
+	}
+
+	var rule models.AlertRule
+	req.ToRule(&rule)
+
+	if err := h.ruleRepo.Create(ctx, &rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create alert rule",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "alert-rules", rule.ID.String(), nil, rule)
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetAlertRuleByID возвращает правило оповещения
+// @Summary Get alert rule by ID
+// @Tags alert-rules
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} models.AlertRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alert-rules/{id} [get]
+func (h *AlertRuleHandler) GetAlertRuleByID(c *gin.Context) {
+	rule, ok := h.findRule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateAlertRule изменяет правило оповещения
+// @Summary Update alert rule
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Param request body models.AlertRuleRequest true "Alert rule"
+// @Success 200 {object} models.AlertRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alert-rules/{id} [put]
+func (h *AlertRuleHandler) UpdateAlertRule(c *gin.Context) {
+	rule, ok := h.findRule(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindRule(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if exists, err := h.ruleRepo.IsNameExistsExcluding(ctx, req.Name, rule.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check alert rule name",
+		})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Alert rule with this name already exists",
+		})
+		return
+	}
+
+	before := *rule
+	req.ToRule(rule)
+
+	if err := h.ruleRepo.Update(ctx, rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update alert rule",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "alert-rules", rule.ID.String(), before, rule)
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteAlertRule удаляет правило оповещения
+// @Summary Delete alert rule
+// @Tags alert-rules
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alert-rules/{id} [delete]
+func (h *AlertRuleHandler) DeleteAlertRule(c *gin.Context) {
+	rule, ok := h.findRule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleRepo.Delete(c.Request.Context(), rule.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete alert rule",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "alert-rules", rule.ID.String(), rule, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// EvaluateAlertRule проверяет правило по текущим метрикам, не сохраняя его
+// @Summary Dry-run an alert rule
+// @Description Evaluate a rule against current metrics without saving it or raising alerts
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param request body models.AlertRuleRequest true "Alert rule"
+// @Success 200 {array} models.RuleEvaluation
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alert-rules/evaluate [post]
+func (h *AlertRuleHandler) EvaluateAlertRule(c *gin.Context) {
+	req, ok := h.bindRule(c)
+	if !ok {
+		return
+	}
+
+	var rule models.AlertRule
+	req.ToRule(&rule)
+
+	h.respondEvaluation(c, rule)
+}
+
+// EvaluateStoredAlertRule проверяет сохраненное правило по текущим метрикам
+// @Summary Dry-run a stored alert rule
+// @Tags alert-rules
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {array} models.RuleEvaluation
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/alert-rules/{id}/evaluate [post]
+func (h *AlertRuleHandler) EvaluateStoredAlertRule(c *gin.Context) {
+	rule, ok := h.findRule(c)
+	if !ok {
+		return
+	}
+
+	h.respondEvaluation(c, *rule)
+}
+
+func (h *AlertRuleHandler) respondEvaluation(c *gin.Context, rule models.AlertRule) {
+	evaluations, err := h.engine.Evaluate(c.Request.Context(), rule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to evaluate alert rule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluations)
+}
+
+// bindRule разбирает и проверяет тело запроса с правилом
+func (h *AlertRuleHandler) bindRule(c *gin.Context) (*models.AlertRuleRequest, bool) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	if req.HostID != nil {
+		host, err := h.hostRepo.FindByID(c.Request.Context(), *req.HostID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to verify host",
+			})
+			return nil, false
+		}
+		if host == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Host not found",
+			})
+			return nil, false
+		}
+	}
+
+	return &req, true
+}
+
+func (h *AlertRuleHandler) findRule(c *gin.Context) (*models.AlertRule, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid alert rule ID format",
+		})
+		return nil, false
+	}
+
+	rule, err := h.ruleRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch alert rule",
+		})
+		return nil, false
+	}
+	if rule == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Alert rule not found",
+		})
+		return nil, false
+	}
+
+	return rule, true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+type AuditHandler struct {
+	auditRepo *postgres.AuditRepository
+}
+
+func NewAuditHandler(auditRepo *postgres.AuditRepository) *AuditHandler {
+	return &AuditHandler{auditRepo: auditRepo}
+}
+
+// GetAuditLog возвращает журнал аудита с пагинацией и фильтрацией
+// @Summary Get audit log
+// @Description Get records of mutating API calls: actor, action, target and changed fields
+// @Tags audit
+// @Produce json
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Number of items per page" default(20) minimum(1) maximum(100)
+// @Param actor query string false "Filter by actor username"
+// @Param action query string false "Filter by action, e.g. UpdateHost"
+// @Param method query string false "Filter by HTTP method" Enums(POST, PUT, PATCH, DELETE)
+// @Param target_type query string false "Filter by target type, e.g. hosts"
+// @Param target_id query string false "Filter by target ID"
+// @Param request_id query string false "Filter by request ID"
+// @Param from query string false "Occurred at or after (RFC3339)"
+// @Param to query string false "Occurred at or before (RFC3339)"
+// @Success 200 {object} models.AuditResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/audit [get]
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	var query models.AuditQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	entries, total, err := h.auditRepo.FindAll(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch audit log",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
+
+	c.JSON(http.StatusOK, models.AuditResponse{
+		Entries:     entries,
+		Total:       total,
+		Page:        query.Page,
+		Limit:       query.Limit,
+		TotalPages:  totalPages,
+		HasNext:     query.Page < totalPages,
+		HasPrevious: query.Page > 1,
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+const secret = "s3cr3t-token"
+
+// auditedChanges возвращает изменения, которые попадут в журнал аудита
+func auditedChanges(t *testing.T, before, after any) map[string]string {
+	t.Helper()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	middleware.AuditChange(c, "test", uuid.NewString(), before, after)
+
+	changes := middleware.AuditChanges(c)
+	data, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatalf("failed to marshal changes: %v", err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Errorf("secret leaked into audit changes: %s", data)
+	}
+
+	fields := make(map[string]string, len(changes))
+	for field, change := range changes {
+		fields[field] = string(change.Before) + " -> " + string(change.After)
+	}
+	return fields
+}
+
+func TestAuditUserRedactsPassword(t *testing.T) {
+	user := func(username, hash string) *models.User {
+		return &models.User{ID: uuid.New(), Username: username, PasswordHash: hash, UpdatedAt: time.Now()}
+	}
+	alice := user("alice", "old-"+secret)
+
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		want   map[string]string
+	}{
+		{
+			name:   "password changed",
+			before: auditUser{User: alice},
+			after:  auditUser{User: &models.User{ID: alice.ID, Username: "alice", PasswordHash: "new-" + secret}, Password: "changed", RevokedTokens: 2},
+			want: map[string]string{
+				"password":       ` -> "changed"`,
+				"revoked_tokens": ` -> 2`,
+			},
+		},
+		{
+			name:   "password unchanged",
+			before: auditUser{User: alice},
+			after:  auditUser{User: &models.User{ID: alice.ID, Username: "bob", PasswordHash: alice.PasswordHash}},
+			want:   map[string]string{"username": `"alice" -> "bob"`},
+		},
+		{
+			name:   "deleted",
+			before: user("carol", secret),
+			after:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := auditedChanges(t, tt.before, tt.after)
+			for field, want := range tt.want {
+				if got[field] != want {
+					t.Errorf("field %q: %s, want %s", field, got[field], want)
+				}
+			}
+			if tt.want != nil && len(got) != len(tt.want) {
+				t.Errorf("changes %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditChannelRedactsConfig(t *testing.T) {
+	channel := func(config string) models.NotificationChannel {
+		return models.NotificationChannel{ID: uuid.New(), Name: "ops", Type: models.ChannelWebhook, Config: json.RawMessage(config)}
+	}
+	withSecret := `{"url":"https://hooks.example.com","token":"` + secret + `"}`
+
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   map[string]string
+	}{
+		{
+			name:   "config changed",
+			before: withSecret,
+			after:  `{"url":"https://hooks.example.com","token":"rotated-` + secret + `"}`,
+			want:   map[string]string{"config": ` -> "changed"`},
+		},
+		{
+			// PostgreSQL возвращает jsonb с другими пробелами и порядком ключей
+			name:   "config reformatted",
+			before: `{"token": "` + secret + `", "url": "https://hooks.example.com"}`,
+			after:  withSecret,
+			want:   map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := channel(tt.before)
+			after := before
+			after.Config = json.RawMessage(tt.after)
+
+			got := auditedChanges(t, auditChannel{NotificationChannel: &before}, auditChannelUpdate(before, &after))
+			if len(got) != len(tt.want) {
+				t.Errorf("changes %v, want %v", got, tt.want)
+			}
+			for field, want := range tt.want {
+				if got[field] != want {
+					t.Errorf("field %q: %s, want %s", field, got[field], want)
+				}
+			}
+		})
+	}
+
+	// Созданный и удаленный канал пишутся без настроек
+	created := channel(withSecret)
+	if got := auditedChanges(t, nil, auditChannel{NotificationChannel: &created}); got["config"] != "" {
+		t.Errorf("created channel config %s, want omitted", got["config"])
+	}
+	auditedChanges(t, auditChannel{NotificationChannel: &created}, nil)
+}
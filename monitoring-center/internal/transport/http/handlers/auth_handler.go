@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type AuthHandler struct {
+	userRepo   *postgres.UserRepository
+	limiter    *middleware.LoginLimiter
+	sessionTTL time.Duration
+}
+
+func NewAuthHandler(userRepo *postgres.UserRepository, limiter *middleware.LoginLimiter, sessionTTL time.Duration) *AuthHandler {
+	return &AuthHandler{userRepo: userRepo, limiter: limiter, sessionTTL: sessionTTL}
+}
+
+// Login выдает токен по имени и паролю
+// @Summary Log in
+// @Description Exchange username and password for an API token that expires after the session TTL. Pass it as "Authorization: Bearer <token>". Repeated failures for a username or from a client address block further attempts for a while with 429 and Retry-After.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginRequest true "Credentials"
+// @Success 201 {object} models.IssuedToken
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if wait := h.limiter.Allow(c.ClientIP(), req.Username); wait > 0 {
+		middleware.TooManyLogins(c, wait)
+		return
+	}
+
+	user, err := h.userRepo.FindByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to authenticate",
+		})
+		return
+	}
+	if !auth.VerifyUser(user, req.Password) {
+		h.limiter.Failed(c.ClientIP(), req.Username)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid username or password",
+		})
+		return
+	}
+	h.limiter.Succeeded(req.Username)
+
+	expiresAt := time.Now().Add(h.sessionTTL)
+	h.issueToken(c, user.ID, "login", &expiresAt)
+}
+
+// GetCurrentUser возвращает аутентифицированного пользователя
+// @Summary Get current user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.User
+// @Failure 401 {object} map[string]string
+// @Router /api/auth/me [get]
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.CurrentUser(c))
+}
+
+// GetTokens возвращает токены текущего пользователя без самих токенов
+// @Summary Get own API tokens
+// @Tags auth
+// @Produce json
+// @Success 200 {array} models.APIToken
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/tokens [get]
+func (h *AuthHandler) GetTokens(c *gin.Context) {
+	tokens, err := h.userRepo.FindTokens(c.Request.Context(), middleware.CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch API tokens",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken выдает текущему пользователю токен для скриптов и интеграций
+// @Summary Create API token
+// @Description Issue a named API token with the current user's role. The token is shown only once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateTokenRequest true "Token data"
+// @Success 201 {object} models.IssuedToken
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/tokens [post]
+func (h *AuthHandler) CreateToken(c *gin.Context) {
+	var req models.CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		expires := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &expires
+	}
+
+	h.issueToken(c, middleware.CurrentUser(c).ID, req.Name, expiresAt)
+}
+
+// RevokeToken отзывает токен текущего пользователя
+// @Summary Revoke API token
+// @Tags auth
+// @Param id path string true "Token ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/tokens/{id} [delete]
+func (h *AuthHandler) RevokeToken(c *gin.Context) {
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid token ID format",
+		})
+		return
+	}
+
+	revoked, err := h.userRepo.RevokeToken(c.Request.Context(), middleware.CurrentUser(c).ID, tokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke API token",
+		})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Active token not found",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "api-tokens", tokenID.String(), gin.H{"revoked": false}, gin.H{"revoked": true})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) issueToken(c *gin.Context, userID uuid.UUID, name string, expiresAt *time.Time) {
+	secret, secretHash, err := auth.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue API token",
+		})
+		return
+	}
+
+	token := models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		ExpiresAt: expiresAt,
+	}
+	if err := h.userRepo.CreateToken(c.Request.Context(), &token, secretHash); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue API token",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "api-tokens", token.ID.String(), nil, token)
+	c.JSON(http.StatusCreated, models.IssuedToken{APIToken: token, Token: secret})
+}
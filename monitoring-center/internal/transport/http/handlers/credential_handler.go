@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type CredentialHandler struct {
+	credentialRepo *postgres.CredentialRepository
+	hostRepo       *postgres.HostRepository
+	agentAuth      *middleware.AgentAuth
+}
+
+func NewCredentialHandler(credentialRepo *postgres.CredentialRepository, hostRepo *postgres.HostRepository, agentAuth *middleware.AgentAuth) *CredentialHandler {
+	return &CredentialHandler{credentialRepo: credentialRepo, hostRepo: hostRepo, agentAuth: agentAuth}
+}
+
+// GetCredentials возвращает ключи агента хоста без секретов
+// @Summary Get host agent credentials
+// @Description Get all agent credentials of the host, including revoked ones. Secrets are never returned.
+// @Tags hosts
+// @Produce json
+// @Param id path string true "Host ID"
+// @Success 200 {array} models.HostCredential
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/credentials [get]
+func (h *CredentialHandler) GetCredentials(c *gin.Context) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.credentialRepo.FindByHost(c.Request.Context(), hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to fetch host credentials",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// RotateCredential выдает хосту новый ключ агента
+// @Summary Rotate host agent credential
+// @Description Issue a new agent credential. Other active credentials are revoked unless keep_previous is set, which lets the agent switch to the new key first. The secret is shown only once.
+// @Tags hosts
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param keep_previous query bool false "Keep other credentials active" default(false)
+// @Success 201 {object} models.RotateCredentialResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/credentials/rotate [post]
+func (h *CredentialHandler) RotateCredential(c *gin.Context) {
+	var query struct {
+		KeepPrevious bool `form:"keep_previous"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+
+	secret, secretHash, err := auth.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue agent credential",
+		})
+		return
+	}
+
+	credential, revoked, err := h.credentialRepo.Issue(c.Request.Context(), hostID, secretHash, !query.KeepPrevious)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to rotate host credential",
+			"details": err.Error(),
+		})
+		return
+	}
+	// Отозванные ключи перестают действовать сразу, а не по истечении кеша
+	h.agentAuth.Forget(hostID)
+
+	middleware.AuditChange(c, "host-credentials", credential.ID.String(), nil, gin.H{
+		"host_id":             hostID,
+		"revoked_credentials": revoked,
+	})
+	c.JSON(http.StatusCreated, models.RotateCredentialResponse{
+		Credential: models.IssuedCredential{ID: credential.ID, Secret: secret},
+		Revoked:    revoked,
+	})
+}
+
+// RevokeCredential отзывает ключ агента хоста
+// @Summary Revoke host agent credential
+// @Description Revoke an active agent credential. Batches signed with it are rejected from now on.
+// @Tags hosts
+// @Param id path string true "Host ID"
+// @Param credential_id path string true "Credential ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/credentials/{credential_id} [delete]
+func (h *CredentialHandler) RevokeCredential(c *gin.Context) {
+	credentialID, err := uuid.Parse(c.Param("credential_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid credential ID format",
+		})
+		return
+	}
+
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.credentialRepo.Revoke(c.Request.Context(), hostID, credentialID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to revoke host credential",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Active credential not found",
+		})
+		return
+	}
+	h.agentAuth.Forget(hostID)
+
+	middleware.AuditChange(c, "host-credentials", credentialID.String(), gin.H{"revoked": false}, gin.H{"revoked": true})
+	c.Status(http.StatusNoContent)
+}
+
+// requireHost разбирает ID хоста из пути и проверяет, что хост существует
+func (h *CredentialHandler) requireHost(c *gin.Context) (uuid.UUID, bool) {
+	hostID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid host ID format",
+		})
+		return uuid.Nil, false
+	}
+
+	host, err := h.hostRepo.FindByID(c.Request.Context(), hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify host",
+		})
+		return uuid.Nil, false
+	}
+	if host == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host not found",
+		})
+		return uuid.Nil, false
+	}
+
+	return hostID, true
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type HostConfigHandler struct {
+	configRepo *postgres.HostConfigRepository
+	hostRepo   *postgres.HostRepository
+}
+
+func NewHostConfigHandler(configRepo *postgres.HostConfigRepository, hostRepo *postgres.HostRepository) *HostConfigHandler {
+	return &HostConfigHandler{
+		configRepo: configRepo,
+		hostRepo:   hostRepo,
+	}
+}
+
+// GetHostConfig возвращает конфигурацию мониторинга хоста
+// @Summary Get host monitoring config
+// @Description Get watched processes, containers and ports of a host
+// @Tags host-config
+// @Produce json
+// @Param id path string true "Host ID"
+// @Success 200 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config [get]
+func (h *HostConfigHandler) GetHostConfig(c *gin.Context) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+
+	h.respondConfig(c, hostID, http.StatusOK)
+}
+
+// ReplaceHostConfig полностью заменяет конфигурацию мониторинга хоста
+// @Summary Replace host monitoring config
+// @Description Replace all watched processes, containers and ports of a host
+// @Tags host-config
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param request body models.UpdateHostConfigRequest true "Host config"
+// @Success 200 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config [put]
+func (h *HostConfigHandler) ReplaceHostConfig(c *gin.Context) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+	before := h.auditState(c, hostID)
+
+	var req models.UpdateHostConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.configRepo.Replace(c.Request.Context(), hostID, req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update host config",
+		})
+		return
+	}
+
+	h.respondChange(c, hostID, before, http.StatusOK)
+}
+
+// AddWatchedProcess добавляет процесс в список наблюдения хоста
+// @Summary Add watched process
+// @Tags host-config
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param request body models.WatchedNameRequest true "Process name"
+// @Success 201 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config/processes [post]
+func (h *HostConfigHandler) AddWatchedProcess(c *gin.Context) {
+	h.addName(c, "Process", h.configRepo.AddProcess)
+}
+
+// RemoveWatchedProcess удаляет процесс из списка наблюдения хоста
+// @Summary Remove watched process
+// @Tags host-config
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param name path string true "Process name"
+// @Success 200 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config/processes/{name} [delete]
+func (h *HostConfigHandler) RemoveWatchedProcess(c *gin.Context) {
+	h.removeName(c, "Process", h.configRepo.RemoveProcess)
+}
+
+// AddWatchedContainer добавляет контейнер в список наблюдения хоста
+// @Summary Add watched container
+// @Tags host-config
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param request body models.WatchedNameRequest true "Container name"
+// @Success 201 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config/containers [post]
+func (h *HostConfigHandler) AddWatchedContainer(c *gin.Context) {
+	h.addName(c, "Container", h.configRepo.AddContainer)
+}
+
+// RemoveWatchedContainer удаляет контейнер из списка наблюдения хоста
+// @Summary Remove watched container
+// @Tags host-config
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param name path string true "Container name"
+// @Success 200 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config/containers/{name} [delete]
+func (h *HostConfigHandler) RemoveWatchedContainer(c *gin.Context) {
+	h.removeName(c, "Container", h.configRepo.RemoveContainer)
+}
+
+// AddWatchedPort добавляет порт в список наблюдения хоста
+// @Summary Add watched port
+// @Tags host-config
+// @Accept json
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param request body models.WatchedPort true "Port"
+// @Success 201 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config/ports [post]
+func (h *HostConfigHandler) AddWatchedPort(c *gin.Context) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+	before := h.auditState(c, hostID)
+
+	var req models.WatchedPort
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	added, err := h.configRepo.AddPort(c.Request.Context(), hostID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add watched port",
+		})
+		return
+	}
+	if !added {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Port is already watched",
+		})
+		return
+	}
+
+	h.respondChange(c, hostID, before, http.StatusCreated)
+}
+
+// RemoveWatchedPort удаляет порт из списка наблюдения хоста
+// @Summary Remove watched port
+// @Tags host-config
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param protocol path string true "Protocol" Enums(tcp, udp)
+// @Param port path int true "Port"
+// @Success 200 {object} models.HostConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/config/ports/{protocol}/{port} [delete]
+func (h *HostConfigHandler) RemoveWatchedPort(c *gin.Context) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+	before := h.auditState(c, hostID)
+
+	port, err := strconv.Atoi(c.Param("port"))
+	protocol := c.Param("protocol")
+	if err != nil || port < 1 || port > 65535 || (protocol != "tcp" && protocol != "udp") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid port or protocol",
+		})
+		return
+	}
+
+	removed, err := h.configRepo.RemovePort(c.Request.Context(), hostID, models.WatchedPort{Port: port, Protocol: protocol})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove watched port",
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Port is not watched",
+		})
+		return
+	}
+
+	h.respondChange(c, hostID, before, http.StatusOK)
+}
+
+// GetAgentConfig отдает конфигурацию агенту. Поддерживает If-None-Match:
+// если версия не изменилась, возвращается 304 без тела.
+// @Summary Poll host config (agent)
+// @Description Used by agents to pull their monitoring config. Returns 304 when the ETag matches
+// @Tags agents
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param If-None-Match header string false "ETag of the config the agent already has"
+// @Success 200 {object} models.HostConfig
+// @Success 304 "Config not modified"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/agents/{id}/config [get]
+func (h *HostConfigHandler) GetAgentConfig(c *gin.Context) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+
+	version, err := h.configRepo.GetVersion(c.Request.Context(), hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch host config",
+		})
+		return
+	}
+
+	etag := configETag(version)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	h.respondConfig(c, hostID, http.StatusOK)
+}
+
+func (h *HostConfigHandler) addName(c *gin.Context, kind string, add func(ctx context.Context, hostID uuid.UUID, name string) (bool, error)) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+	before := h.auditState(c, hostID)
+
+	var req models.WatchedNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	added, err := add(c.Request.Context(), hostID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to add watched %s", strings.ToLower(kind)),
+		})
+		return
+	}
+	if !added {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("%s is already watched", kind),
+		})
+		return
+	}
+
+	h.respondChange(c, hostID, before, http.StatusCreated)
+}
+
+func (h *HostConfigHandler) removeName(c *gin.Context, kind string, remove func(ctx context.Context, hostID uuid.UUID, name string) (bool, error)) {
+	hostID, ok := h.requireHost(c)
+	if !ok {
+		return
+	}
+	before := h.auditState(c, hostID)
+
+	removed, err := remove(c.Request.Context(), hostID, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to remove watched %s", strings.ToLower(kind)),
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("%s is not watched", kind),
+		})
+		return
+	}
+
+	h.respondChange(c, hostID, before, http.StatusOK)
+}
+
+// requireHost разбирает ID хоста из пути и проверяет, что хост существует
+func (h *HostConfigHandler) requireHost(c *gin.Context) (uuid.UUID, bool) {
+	hostID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid host ID format",
+		})
+		return uuid.Nil, false
+	}
+
+	host, err := h.hostRepo.FindByID(c.Request.Context(), hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify host",
+		})
+		return uuid.Nil, false
+	}
+	if host == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host not found",
+		})
+		return uuid.Nil, false
+	}
+
+	return hostID, true
+}
+
+func (h *HostConfigHandler) respondConfig(c *gin.Context, hostID uuid.UUID, status int) {
+	config, err := h.configRepo.Get(c.Request.Context(), hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch host config",
+		})
+		return
+	}
+
+	c.Header("ETag", configETag(config.Version))
+	c.JSON(status, config)
+}
+
+// auditState возвращает конфигурацию до изменения для журнала аудита
+func (h *HostConfigHandler) auditState(c *gin.Context, hostID uuid.UUID) *models.HostConfig {
+	config, err := h.configRepo.Get(c.Request.Context(), hostID)
+	if err != nil {
+		return nil
+	}
+	return config
+}
+
+// respondChange отвечает измененной конфигурацией и передает ее в журнал аудита
+func (h *HostConfigHandler) respondChange(c *gin.Context, hostID uuid.UUID, before *models.HostConfig, status int) {
+	config, err := h.configRepo.Get(c.Request.Context(), hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch host config",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "host-config", hostID.String(), before, config)
+	c.Header("ETag", configETag(config.Version))
+	c.JSON(status, config)
+}
+
+func configETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/service/election"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type HostGroupHandler struct {
+	groupRepo  *postgres.HostGroupRepository
+	hostRepo   *postgres.HostRepository
+	masterRepo *postgres.MasterRepository
+	elector    *election.Elector
+}
+
+func NewHostGroupHandler(groupRepo *postgres.HostGroupRepository, hostRepo *postgres.HostRepository, masterRepo *postgres.MasterRepository, elector *election.Elector) *HostGroupHandler {
+	return &HostGroupHandler{
+		groupRepo:  groupRepo,
+		hostRepo:   hostRepo,
+		masterRepo: masterRepo,
+		elector:    elector,
+	}
+}
+
+// GetGroups возвращает все группы хостов
+// @Summary Get host groups
+// @Tags groups
+// @Produce json
+// @Success 200 {array} models.HostGroup
+// @Failure 500 {object} map[string]string
+// @Router /api/groups [get]
+func (h *HostGroupHandler) GetGroups(c *gin.Context) {
+	groups, err := h.groupRepo.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch host groups",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// CreateGroup создает группу хостов со своими выборами мастера
+// @Summary Create host group
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param request body models.HostGroupRequest true "Host group"
+// @Success 201 {object} models.HostGroup
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups [post]
+func (h *HostGroupHandler) CreateGroup(c *gin.Context) {
+	var req models.HostGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if !h.checkName(c, req.Name, uuid.Nil) {
+		return
+	}
+
+	group := models.HostGroup{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := h.groupRepo.Create(ctx, &group); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create host group",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "groups", group.ID.String(), nil, group)
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetGroupByID возвращает группу хостов
+// @Summary Get host group by ID
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} models.HostGroup
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id} [get]
+func (h *HostGroupHandler) GetGroupByID(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// UpdateGroup изменяет группу хостов
+// @Summary Update host group
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body models.HostGroupRequest true "Host group"
+// @Success 200 {object} models.HostGroup
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id} [put]
+func (h *HostGroupHandler) UpdateGroup(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.HostGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !h.checkName(c, req.Name, group.ID) {
+		return
+	}
+
+	before := *group
+	group.Name = req.Name
+	group.Description = req.Description
+
+	if err := h.groupRepo.Update(c.Request.Context(), group); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update host group",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "groups", group.ID.String(), before, group)
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup удаляет группу вместе с историей ее мастера. Хосты не удаляются.
+// @Summary Delete host group
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id} [delete]
+func (h *HostGroupHandler) DeleteGroup(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	if err := h.groupRepo.Delete(c.Request.Context(), group.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete host group",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "groups", group.ID.String(), group, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// GetGroupMembers возвращает хосты группы
+// @Summary Get host group members
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {array} models.Host
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id}/members [get]
+func (h *HostGroupHandler) GetGroupMembers(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	hosts, err := h.groupRepo.FindMembers(c.Request.Context(), group.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch group members",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hosts)
+}
+
+// AddGroupMember добавляет хост в группу
+// @Summary Add host to group
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param host_id path string true "Host ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id}/members/{host_id} [put]
+func (h *HostGroupHandler) AddGroupMember(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	hostID, err := uuid.Parse(c.Param("host_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid host ID format",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	host, err := h.hostRepo.FindByID(ctx, hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch host",
+		})
+		return
+	}
+	if host == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host not found",
+		})
+		return
+	}
+
+	if err := h.groupRepo.AddMember(ctx, group.ID, host.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add host to group",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "groups", group.ID.String(), nil, gin.H{"member": host.ID})
+	h.elector.Trigger()
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveGroupMember исключает хост из группы
+// @Summary Remove host from group
+// @Description If the host was the group master, a new master is elected
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param host_id path string true "Host ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id}/members/{host_id} [delete]
+func (h *HostGroupHandler) RemoveGroupMember(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	hostID, err := uuid.Parse(c.Param("host_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid host ID format",
+		})
+		return
+	}
+
+	removed, err := h.groupRepo.RemoveMember(c.Request.Context(), group.ID, hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove host from group",
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host is not a member of this group",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "groups", group.ID.String(), gin.H{"member": hostID}, nil)
+	h.elector.Trigger()
+	c.Status(http.StatusNoContent)
+}
+
+// GetGroupMaster возвращает текущий мастер-хост группы
+// @Summary Get group master host
+// @Description Get the current master of the group from its lease. The lease term (fencing token) is returned in the X-Master-Term header.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} models.Host
+// @Success 204 "No master host available"
+// @Header 200,204 {integer} X-Master-Term "Lease term, increases on every master change"
+// @Header 200 {string} X-Master-Lease-Expires "Lease expiration time (RFC3339)"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id}/master [get]
+func (h *HostGroupHandler) GetGroupMaster(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid group ID format",
+		})
+		return
+	}
+
+	respondMaster(c, h.hostRepo, h.masterRepo, id)
+}
+
+// GetGroupMasterHistory возвращает историю смены мастера группы
+// @Summary Get group master history
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param limit query int false "Limit results" default(100) minimum(1) maximum(1000)
+// @Success 200 {array} models.MasterChange
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/groups/{id}/master/history [get]
+func (h *HostGroupHandler) GetGroupMasterHistory(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	respondMasterHistory(c, h.masterRepo, group.ID)
+}
+
+// GetAgentRole возвращает роль хоста в группе. Агент опрашивает этот
+// эндпоинт и запускает скрипты повышения и понижения.
+// @Summary Get agent role
+// @Description Get whether the host is the current master of a group. Without the group parameter the default group (all hosts) is used.
+// @Tags agents
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param group query string false "Group ID or name"
+// @Success 200 {object} models.AgentRole
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/agents/{id}/role [get]
+func (h *HostGroupHandler) GetAgentRole(c *gin.Context) {
+	hostID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid host ID format",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	role := models.AgentRole{
+		HostID:    hostID,
+		GroupID:   models.DefaultGroupID,
+		GroupName: "default",
+		Role:      models.RoleBackup,
+	}
+
+	if name := c.Query("group"); name != "" {
+		var group *models.HostGroup
+		if id, parseErr := uuid.Parse(name); parseErr == nil {
+			group, err = h.groupRepo.FindByID(ctx, id)
+		} else {
+			group, err = h.groupRepo.FindByName(ctx, name)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to fetch host group",
+			})
+			return
+		}
+		if group == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Host group not found",
+			})
+			return
+		}
+		role.GroupID = group.ID
+		role.GroupName = group.Name
+	}
+
+	host, err := h.hostRepo.FindGroupHost(ctx, role.GroupID, hostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch host",
+		})
+		return
+	}
+	if host == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host not found in this group",
+		})
+		return
+	}
+
+	lease, err := h.masterRepo.GetLease(ctx, role.GroupID)
+	if err != nil || lease == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get master lease",
+		})
+		return
+	}
+
+	// По истекшей аренде мастера нет: хост не должен оставаться мастером,
+	// если центр перестал подтверждать его роль
+	role.Term = lease.Term
+	role.MasterHostID = lease.ActiveMaster(time.Now())
+	if role.MasterHostID != nil && *role.MasterHostID == hostID {
+		role.Role = models.RoleMaster
+		role.LeaseExpiresAt = lease.ExpiresAt
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *HostGroupHandler) checkName(c *gin.Context, name string, excludeID uuid.UUID) bool {
+	exists, err := h.groupRepo.IsNameExistsExcluding(c.Request.Context(), name, excludeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check host group name",
+		})
+		return false
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Host group with this name already exists",
+		})
+		return false
+	}
+
+	return true
+}
+
+func (h *HostGroupHandler) findGroup(c *gin.Context) (*models.HostGroup, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid group ID format",
+		})
+		return nil, false
+	}
+
+	group, err := h.groupRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch host group",
+		})
+		return nil, false
+	}
+	if group == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host group not found",
+		})
+		return nil, false
+	}
+
+	return group, true
+}
@@ -3,29 +3,35 @@ package handlers
 import (
 	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
 	"github.com/nekitmilk/monitoring-center/internal/models"
 	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
 )
 
 type HostHandler struct {
-	hostRepo *postgres.HostRepository
+	hostRepo       *postgres.HostRepository
+	masterRepo     *postgres.MasterRepository
+	credentialRepo *postgres.CredentialRepository
+	agentAuth      *middleware.AgentAuth
 }
 
-func NewHostHandler(hostRepo *postgres.HostRepository) *HostHandler {
-	return &HostHandler{hostRepo: hostRepo}
+func NewHostHandler(hostRepo *postgres.HostRepository, masterRepo *postgres.MasterRepository, credentialRepo *postgres.CredentialRepository, agentAuth *middleware.AgentAuth) *HostHandler {
+	return &HostHandler{hostRepo: hostRepo, masterRepo: masterRepo, credentialRepo: credentialRepo, agentAuth: agentAuth}
 }
 
 // CreateHost создает новый хост
 // @Summary Create new host
-// @Description Add a new host to monitoring system
+// @Description Add a new host to monitoring system. The response includes the agent key, its secret is shown only once.
 // @Tags hosts
 // @Accept json
 // @Produce json
 // @Param request body models.CreateHostRequest true "Host data"
-// @Success 201 {object} models.Host  // Успех: 201 Created, вернет объект Host
+// @Success 201 {object} models.CreateHostResponse  // Успех: 201 Created, вернет хост и ключ агента
 // @Failure 400 {object} map[string]string  // Ошибка клиента
 // @Failure 409 {object} map[string]string  // Конфликт (дубликат)
 // @Failure 500 {object} map[string]string  // Ошибка сервера
@@ -72,16 +78,31 @@ func (h *HostHandler) CreateHost(c *gin.Context) {
 		Name:     req.Name,
 		IP:       req.IP,
 		Priority: req.Priority,
+		Labels:   req.Labels,
 	}
 
-	if err := h.hostRepo.Create(ctx, host); err != nil {
+	secret, secretHash, err := auth.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to issue agent credential",
+		})
+		return
+	}
+
+	// Хост создается вместе с ключом агента, без ключа агент не сможет отправлять метрики
+	credential, err := h.credentialRepo.CreateHost(ctx, host, secretHash)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create host",
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, host)
+	middleware.AuditChange(c, "hosts", host.ID.String(), nil, host)
+	c.JSON(http.StatusCreated, models.CreateHostResponse{
+		Host:       *host,
+		Credential: models.IssuedCredential{ID: credential.ID, Secret: secret},
+	})
 }
 
 // GetHosts возвращает список хостов с пагинацией и фильтрацией
@@ -94,6 +115,7 @@ func (h *HostHandler) CreateHost(c *gin.Context) {
 // @Param status query string false "Filter by status" Enums(online, offline, unknown)
 // @Param priority query int false "Filter by priority" minimum(1) maximum(100)
 // @Param search query string false "Search by name or IP"
+// @Param label query string false "Filter by label"
 // @Success 200 {object} models.HostsResponse
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -261,10 +283,14 @@ func (h *HostHandler) UpdateHost(c *gin.Context) {
 		return
 	}
 
+	// Состояние до изменения для журнала аудита
+	before := *existingHost
+
 	// Обновляем данные
 	existingHost.Name = req.Name
 	existingHost.IP = req.IP
 	existingHost.Priority = req.Priority
+	existingHost.Labels = req.Labels
 
 	if err := h.hostRepo.Update(ctx, existingHost); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -273,6 +299,7 @@ func (h *HostHandler) UpdateHost(c *gin.Context) {
 		return
 	}
 
+	middleware.AuditChange(c, "hosts", id.String(), before, existingHost)
 	c.JSON(http.StatusOK, existingHost)
 }
 
@@ -320,34 +347,96 @@ func (h *HostHandler) DeleteHost(c *gin.Context) {
 		return
 	}
 
+	// Ключи удаляются вместе с хостом
+	h.agentAuth.Forget(id)
+
+	middleware.AuditChange(c, "hosts", id.String(), existingHost, nil)
 	c.Status(http.StatusNoContent)
 }
 
 // GetMasterHost возвращает текущий мастер-хост
 // @Summary Get master host
-// @Description Get the current master host (host with highest priority among online hosts)
+// @Description Get the current master host of the default group (all hosts) from the master lease. The lease term (fencing token) is returned in the X-Master-Term header.
 // @Tags hosts
 // @Produce json
 // @Success 200 {object} models.Host
 // @Success 204 "No master host available"
+// @Header 200,204 {integer} X-Master-Term "Lease term, increases on every master change"
+// @Header 200 {string} X-Master-Lease-Expires "Lease expiration time (RFC3339)"
 // @Failure 500 {object} map[string]string
 // @Router /api/hosts/master [get]
 func (h *HostHandler) GetMasterHost(c *gin.Context) {
+	respondMaster(c, h.hostRepo, h.masterRepo, models.DefaultGroupID)
+}
+
+// GetMasterHistory возвращает историю смены мастера
+// @Summary Get master history
+// @Description Get master changes with their terms and reasons, newest first
+// @Tags hosts
+// @Produce json
+// @Param limit query int false "Limit results" default(100) minimum(1) maximum(1000)
+// @Success 200 {array} models.MasterChange
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/master/history [get]
+func (h *HostHandler) GetMasterHistory(c *gin.Context) {
+	respondMasterHistory(c, h.masterRepo, models.DefaultGroupID)
+}
+
+// GetHostStatusHistory возвращает историю переходов статуса хоста
+// @Summary Get host status history
+// @Description Get online/offline transitions of a host, newest first
+// @Tags hosts
+// @Produce json
+// @Param id path string true "Host ID"
+// @Param limit query int false "Limit results" default(100) minimum(1) maximum(1000)
+// @Success 200 {array} models.HostStatusChange
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/hosts/{id}/status-history [get]
+func (h *HostHandler) GetHostStatusHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid host ID format",
+		})
+		return
+	}
+
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit",
+			})
+			return
+		}
+	}
+
 	ctx := c.Request.Context()
 
-	masterHost, err := h.hostRepo.FindMasterHost(ctx)
+	host, err := h.hostRepo.FindByID(ctx, id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to find master host",
-			"details": err.Error(),
+			"error": "Failed to fetch host",
+		})
+		return
+	}
+	if host == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host not found",
 		})
 		return
 	}
 
-	if masterHost == nil {
-		c.Status(http.StatusNoContent)
+	history, err := h.hostRepo.FindStatusHistory(ctx, id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch status history",
+		})
 		return
 	}
 
-	c.JSON(http.StatusOK, masterHost)
+	c.JSON(http.StatusOK, history)
 }
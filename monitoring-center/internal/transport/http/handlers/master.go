@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+// respondMaster отвечает мастер-хостом группы из аренды.
+// Term аренды (fencing-токен) передается в заголовке X-Master-Term.
+func respondMaster(c *gin.Context, hostRepo *postgres.HostRepository, masterRepo *postgres.MasterRepository, groupID uuid.UUID) {
+	ctx := c.Request.Context()
+
+	lease, err := masterRepo.GetLease(ctx, groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to find master host",
+			"details": err.Error(),
+		})
+		return
+	}
+	if lease == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Host group not found",
+		})
+		return
+	}
+
+	c.Header("X-Master-Term", strconv.FormatInt(lease.Term, 10))
+
+	// Истекшая аренда мастера не подтверждает
+	masterID := lease.ActiveMaster(time.Now())
+	if masterID == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	masterHost, err := hostRepo.FindByID(ctx, *masterID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to find master host",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if masterHost == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if lease.ExpiresAt != nil {
+		c.Header("X-Master-Lease-Expires", lease.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	c.JSON(http.StatusOK, masterHost)
+}
+
+// respondMasterHistory отвечает историей смены мастера группы
+func respondMasterHistory(c *gin.Context, masterRepo *postgres.MasterRepository, groupID uuid.UUID) {
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit",
+			})
+			return
+		}
+	}
+
+	history, err := masterRepo.FindHistory(c.Request.Context(), groupID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch master history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
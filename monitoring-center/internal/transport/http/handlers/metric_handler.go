@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -8,33 +11,45 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/service/ingest"
+	"github.com/nekitmilk/monitoring-center/internal/service/liveness"
 	"github.com/nekitmilk/monitoring-center/internal/storage/mongo"
-	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
 )
 
 type MetricHandler struct {
 	metricRepo *mongo.MetricRepository
-	hostRepo   *postgres.HostRepository
+	agentAuth  *middleware.AgentAuth
+	tracker    *liveness.Tracker
+	pipeline   *ingest.Pipeline
+	retryAfter time.Duration // Через сколько агенту повторить пакет, если очередь заполнена
 }
 
-func NewMetricHandler(metricRepo *mongo.MetricRepository, hostRepo *postgres.HostRepository) *MetricHandler {
+func NewMetricHandler(metricRepo *mongo.MetricRepository, agentAuth *middleware.AgentAuth, tracker *liveness.Tracker, pipeline *ingest.Pipeline, retryAfter time.Duration) *MetricHandler {
 	return &MetricHandler{
 		metricRepo: metricRepo,
-		hostRepo:   hostRepo,
+		agentAuth:  agentAuth,
+		tracker:    tracker,
+		pipeline:   pipeline,
+		retryAfter: retryAfter,
 	}
 }
 
 // ReceiveMetrics принимает метрики от агента
 // @Summary Receive metrics from agent
-// @Description Receive monitoring metrics from agent
+// @Description Receive monitoring metrics from agent. Accepted batches are queued and written asynchronously; when the queue is full the center answers 429 with Retry-After. The batch must carry an active agent key of host_id in the X-Agent-Key header or come over mutual TLS with a client certificate issued for host_id.
 // @Tags metrics
 // @Accept json
 // @Produce json
+// @Param X-Agent-Key header string false "Agent key secret"
 // @Param request body models.MetricsRequest true "Metrics data"
 // @Success 202 {object} map[string]string
 // @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Client certificate does not match host"
+// @Failure 429 {object} map[string]string "Ingest queue is full"
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string "Center is shutting down"
 // @Router /api/metrics [post]
 func (h *MetricHandler) ReceiveMetrics(c *gin.Context) {
 	var req models.MetricsRequest
@@ -47,8 +62,6 @@ func (h *MetricHandler) ReceiveMetrics(c *gin.Context) {
 		return
 	}
 
-	// Проверяем существование хоста
-	ctx := c.Request.Context()
 	hostID, err := uuid.Parse(req.HostID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -57,25 +70,30 @@ func (h *MetricHandler) ReceiveMetrics(c *gin.Context) {
 		return
 	}
 
-	host, err := h.hostRepo.FindByID(ctx, hostID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to verify host",
-		})
+	// Ключ агента или сертификат выдаются конкретному хосту, поэтому
+	// успешная проверка заодно подтверждает, что хост существует
+	if !h.agentAuth.Verify(c, hostID) {
 		return
 	}
 
-	if host == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Host not found",
-		})
-		return
-	}
+	// Агент на связи, даже если MongoDB недоступна или очередь заполнена, поэтому
+	// хост отмечается активным при приеме пакета, а не после записи метрик
+	h.tracker.RecordSeen(hostID)
 
-	// Сохраняем метрики
-	if err := h.metricRepo.SaveMetrics(ctx, req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to save metrics",
+	// Метрики записываются в фоне
+	if err := h.pipeline.Enqueue(req); err != nil {
+		retryAfter := int(math.Ceil(h.retryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+
+		if errors.Is(err, ingest.ErrClosed) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Metrics ingest is shutting down",
+			})
+			return
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Metrics ingest queue is full",
+			"details": fmt.Sprintf("%d batches are waiting to be written", h.pipeline.Len()),
 		})
 		return
 	}
@@ -92,7 +110,7 @@ func (h *MetricHandler) ReceiveMetrics(c *gin.Context) {
 // @Tags metrics
 // @Produce json
 // @Param host_id path string true "Host ID"
-// @Param type query string false "Metric type" Enums(cpu, ram, disk, process, port, container)
+// @Param type query string false "Metric type" Enums(cpu, ram, disk, disk_io, process, port, container, agent, network, pressure)
 // @Param from query string false "Start time (RFC3339)"
 // @Param to query string false "End time (RFC3339)"
 // @Param limit query int false "Limit results" default(100) minimum(1) maximum(1000)
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/service/ingest"
+	"github.com/nekitmilk/monitoring-center/internal/service/liveness"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type nopStore struct{}
+
+func (nopStore) InsertMetrics(ctx context.Context, documents []interface{}) error { return nil }
+
+func TestReceiveMetricsBackpressure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Воркеры не запущены: очередь из одного пакета заполняется первым же запросом
+	pipeline := ingest.NewPipeline(nopStore{}, 1, 1, 10, time.Hour, time.Second)
+	// Агенты без ключа разрешены, поэтому ни проверка агента, ни отметка хоста не обращаются к БД
+	handler := NewMetricHandler(nil, middleware.NewAgentAuth(nil, true, time.Minute),
+		liveness.NewTracker(nil, time.Minute, 3, time.Minute), pipeline, 1500*time.Millisecond)
+
+	router := gin.New()
+	router.POST("/api/metrics", handler.ReceiveMetrics)
+
+	body := `{"host_id":"` + uuid.NewString() + `","metrics":[{"type":"cpu","value":42}]}`
+	post := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/metrics", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if resp := post(); resp.Code != http.StatusAccepted {
+		t.Fatalf("first batch: status %d, want 202: %s", resp.Code, resp.Body)
+	}
+
+	resp := post()
+	if resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("full queue: status %d, want 429: %s", resp.Code, resp.Body)
+	}
+	// Задержка округляется вверх до целых секунд
+	if got := resp.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After %q, want 2", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	pipeline.Start()
+	if err := pipeline.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	resp = post()
+	if resp.Code != http.StatusServiceUnavailable || resp.Header().Get("Retry-After") == "" {
+		t.Errorf("after shutdown: status %d Retry-After %q, want 503 with Retry-After", resp.Code, resp.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/service/notifier"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type NotificationHandler struct {
+	repo       *postgres.NotificationRepository
+	dispatcher *notifier.Dispatcher
+}
+
+func NewNotificationHandler(repo *postgres.NotificationRepository, dispatcher *notifier.Dispatcher) *NotificationHandler {
+	return &NotificationHandler{
+		repo:       repo,
+		dispatcher: dispatcher,
+	}
+}
+
+// GetChannels возвращает все каналы уведомлений
+// @Summary Get notification channels
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} models.NotificationChannel
+// @Failure 500 {object} map[string]string
+// @Router /api/notification-channels [get]
+func (h *NotificationHandler) GetChannels(c *gin.Context) {
+	channels, err := h.repo.FindChannels(c.Request.Context(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch notification channels",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, channels)
+}
+
+// CreateChannel создает канал уведомлений
+// @Summary Create notification channel
+// @Description Create a webhook, email or telegram channel subscribed to host status and alert events
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body models.NotificationChannelRequest true "Notification channel"
+// @Success 201 {object} models.NotificationChannel
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notification-channels [post]
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	req, ok := h.bindChannel(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if exists, err := h.repo.IsChannelNameExistsExcluding(ctx, req.Name, uuid.Nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check notification channel name",
+		})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Notification channel with this name already exists",
+		})
+		return
+	}
+
+	var channel models.NotificationChannel
+	req.ToChannel(&channel)
+
+	if err := h.repo.CreateChannel(ctx, &channel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create notification channel",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "notification-channels", channel.ID.String(), nil, auditChannel{NotificationChannel: &channel})
+	c.JSON(http.StatusCreated, channel)
+}
+
+// GetChannelByID возвращает канал уведомлений
+// @Summary Get notification channel by ID
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Success 200 {object} models.NotificationChannel
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notification-channels/{id} [get]
+func (h *NotificationHandler) GetChannelByID(c *gin.Context) {
+	channel, ok := h.findChannel(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// UpdateChannel изменяет канал уведомлений
+// @Summary Update notification channel
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Param request body models.NotificationChannelRequest true "Notification channel"
+// @Success 200 {object} models.NotificationChannel
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notification-channels/{id} [put]
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	channel, ok := h.findChannel(c)
+	if !ok {
+		return
+	}
+
+	req, ok := h.bindChannel(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if exists, err := h.repo.IsChannelNameExistsExcluding(ctx, req.Name, channel.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check notification channel name",
+		})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Notification channel with this name already exists",
+		})
+		return
+	}
+
+	before := *channel
+	req.ToChannel(channel)
+
+	if err := h.repo.UpdateChannel(ctx, channel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update notification channel",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "notification-channels", channel.ID.String(),
+		auditChannel{NotificationChannel: &before}, auditChannelUpdate(before, channel))
+	c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannel удаляет канал уведомлений вместе с журналом доставки
+// @Summary Delete notification channel
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notification-channels/{id} [delete]
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	channel, ok := h.findChannel(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteChannel(c.Request.Context(), channel.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete notification channel",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "notification-channels", channel.ID.String(), auditChannel{NotificationChannel: channel}, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// TestChannel синхронно отправляет в канал тестовое уведомление
+// @Summary Send test notification
+// @Description Deliver a test message with retries and return the delivery log entry
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Success 200 {object} models.NotificationDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} models.NotificationDelivery
+// @Router /api/notification-channels/{id}/test [post]
+func (h *NotificationHandler) TestChannel(c *gin.Context) {
+	channel, ok := h.findChannel(c)
+	if !ok {
+		return
+	}
+
+	delivery := h.dispatcher.Deliver(c.Request.Context(), *channel, notifier.TestMessage(*channel))
+	if delivery.Status != models.DeliverySent {
+		c.JSON(http.StatusBadGateway, delivery)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// GetDeliveries возвращает журнал доставки канала
+// @Summary Get notification delivery log
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Param limit query int false "Number of entries" default(50) minimum(1) maximum(500)
+// @Success 200 {array} models.NotificationDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notification-channels/{id}/deliveries [get]
+func (h *NotificationHandler) GetDeliveries(c *gin.Context) {
+	channel, ok := h.findChannel(c)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit must be between 1 and 500",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.repo.FindDeliveries(c.Request.Context(), channel.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch notification deliveries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// bindChannel разбирает тело запроса и проверяет настройки канала
+func (h *NotificationHandler) bindChannel(c *gin.Context) (*models.NotificationChannelRequest, bool) {
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	if _, err := notifier.New(req.Type, req.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid channel config",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+func (h *NotificationHandler) findChannel(c *gin.Context) (*models.NotificationChannel, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification channel ID format",
+		})
+		return nil, false
+	}
+
+	channel, err := h.repo.FindChannelByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch notification channel",
+		})
+		return nil, false
+	}
+	if channel == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Notification channel not found",
+		})
+		return nil, false
+	}
+
+	return channel, true
+}
+
+// auditChannel канал для журнала аудита. В настройках канала бывают пароли
+// и токены, поэтому вместо них пишется только признак изменения.
+type auditChannel struct {
+	*models.NotificationChannel
+	Config string `json:"config,omitempty"`
+}
+
+// auditChannelUpdate возвращает измененный канал для журнала аудита с отметкой
+// о смене настроек, если они отличаются от before
+func auditChannelUpdate(before models.NotificationChannel, channel *models.NotificationChannel) auditChannel {
+	after := auditChannel{NotificationChannel: channel}
+	if !sameJSON(before.Config, channel.Config) {
+		after.Config = "changed"
+	}
+	return after
+}
+
+// sameJSON сравнивает JSON по значению: PostgreSQL хранит jsonb в своем формате
+func sameJSON(a, b json.RawMessage) bool {
+	var left, right any
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(left, right)
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+	"github.com/nekitmilk/monitoring-center/internal/transport/http/middleware"
+)
+
+type UserHandler struct {
+	userRepo *postgres.UserRepository
+}
+
+func NewUserHandler(userRepo *postgres.UserRepository) *UserHandler {
+	return &UserHandler{userRepo: userRepo}
+}
+
+// GetUsers возвращает всех пользователей
+// @Summary Get users
+// @Tags users
+// @Produce json
+// @Success 200 {array} models.User
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users [get]
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	users, err := h.userRepo.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch users",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// CreateUser создает пользователя
+// @Summary Create user
+// @Description Create an API user with the viewer, operator or admin role
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.CreateUserRequest true "User data"
+// @Success 201 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users [post]
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if exists, err := h.userRepo.IsUsernameExists(ctx, req.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check username",
+		})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "User with this username already exists",
+		})
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create user",
+		})
+		return
+	}
+
+	user := &models.User{
+		Username:     req.Username,
+		PasswordHash: passwordHash,
+		Role:         req.Role,
+	}
+
+	if err := h.userRepo.Create(ctx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create user",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "users", user.ID.String(), nil, user)
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetUserByID возвращает пользователя
+// @Summary Get user by ID
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{id} [get]
+func (h *UserHandler) GetUserByID(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUser меняет пароль, роль или блокировку пользователя
+// @Summary Update user
+// @Description Change password, role or disabled flag. Changing the password or disabling the user revokes all their API tokens, including login sessions. The last active admin cannot be demoted or disabled.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body models.UpdateUserRequest true "User changes"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Last active admin"
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{id} [put]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	var req models.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid input data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	wasAdmin := isActiveAdmin(user)
+	before := *user
+
+	if req.Password != "" {
+		passwordHash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to update user",
+			})
+			return
+		}
+		user.PasswordHash = passwordHash
+	}
+	if req.Role != "" {
+		user.Role = req.Role
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+
+	if wasAdmin && !isActiveAdmin(user) && !h.keepsAdmin(c, user.ID) {
+		return
+	}
+
+	// Сессии, выданные до смены пароля или блокировки, перестают действовать
+	revokeTokens := req.Password != "" || user.Disabled
+	revoked, err := h.userRepo.Update(c.Request.Context(), user, revokeTokens)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update user",
+		})
+		return
+	}
+
+	// Хеш пароля в журнал не попадает, отмечается только факт смены
+	after := auditUser{User: user, RevokedTokens: revoked}
+	if req.Password != "" {
+		after.Password = "changed"
+	}
+	middleware.AuditChange(c, "users", user.ID.String(), auditUser{User: &before}, after)
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser удаляет пользователя вместе с его токенами
+// @Summary Delete user
+// @Description Delete a user and all their API tokens. The last active admin cannot be deleted.
+// @Tags users
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Last active admin"
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{id} [delete]
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+
+	if isActiveAdmin(user) && !h.keepsAdmin(c, user.ID) {
+		return
+	}
+
+	if err := h.userRepo.Delete(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete user",
+		})
+		return
+	}
+
+	middleware.AuditChange(c, "users", user.ID.String(), user, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// keepsAdmin проверяет, что без пользователя userID останется хотя бы один
+// включенный администратор, иначе управлять пользователями будет некому
+func (h *UserHandler) keepsAdmin(c *gin.Context, userID uuid.UUID) bool {
+	count, err := h.userRepo.CountActiveAdmins(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check admins",
+		})
+		return false
+	}
+	if count == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Cannot remove the last active admin",
+		})
+		return false
+	}
+	return true
+}
+
+// auditUser пользователь для журнала аудита с признаком смены пароля
+type auditUser struct {
+	*models.User
+	Password      string `json:"password,omitempty"`
+	RevokedTokens int64  `json:"revoked_tokens,omitempty"`
+}
+
+func isActiveAdmin(user *models.User) bool {
+	return user.Role == models.UserRoleAdmin && !user.Disabled
+}
+
+func (h *UserHandler) findUser(c *gin.Context) (*models.User, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID format",
+		})
+		return nil, false
+	}
+
+	user, err := h.userRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch user",
+		})
+		return nil, false
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/pki"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+// Таймаут записи времени использования ключей
+const keyUsageWriteTimeout = 5 * time.Second
+
+// AgentAuth проверяет, что запрос пришел от агента хоста: по ключу агента
+// в заголовке X-Agent-Key или по клиентскому сертификату, выпущенному для хоста.
+// Результат проверки ключа кешируется на cacheTTL, а время использования ключей
+// копится в памяти и записывается в Run, поэтому прием метрик не ходит в PostgreSQL.
+type AgentAuth struct {
+	credentialRepo *postgres.CredentialRepository
+	// Принимать запросы без ключа агента (агенты, настроенные до появления ключей)
+	allowAnonymous bool
+	cacheTTL       time.Duration
+
+	mu   sync.Mutex
+	keys map[string]cachedKey // Хеш ключа -> хост
+	used map[string]time.Time // Хеш ключа -> время использования, еще не записанное в БД
+}
+
+type cachedKey struct {
+	hostID    *uuid.UUID // nil - ключ не найден или отозван
+	expiresAt time.Time
+}
+
+func NewAgentAuth(credentialRepo *postgres.CredentialRepository, allowAnonymous bool, cacheTTL time.Duration) *AgentAuth {
+	if cacheTTL <= 0 {
+		cacheTTL = 30 * time.Second
+	}
+
+	return &AgentAuth{
+		credentialRepo: credentialRepo,
+		allowAnonymous: allowAnonymous,
+		cacheTTL:       cacheTTL,
+		keys:           make(map[string]cachedKey),
+		used:           make(map[string]time.Time),
+	}
+}
+
+// Run раз в cacheTTL записывает время использования ключей и чистит кеш.
+// При отмене ctx записывает накопленное.
+func (a *AgentAuth) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cacheTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			a.flush(ctx)
+			a.prune(time.Now())
+		}
+	}
+}
+
+// Forget сбрасывает кешированные ключи хоста, например после отзыва ключа
+func (a *AgentAuth) Forget(hostID uuid.UUID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for hash, key := range a.keys {
+		if key.hostID != nil && *key.hostID == hostID {
+			delete(a.keys, hash)
+		}
+	}
+}
+
+// RequireAgent пропускает запросы агента хоста из параметра пути :id
+func (a *AgentAuth) RequireAgent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hostID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid host ID format",
+			})
+			return
+		}
+
+		if !a.Verify(c, hostID) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Verify проверяет, что запрос пришел от агента хоста hostID.
+// При отказе отвечает клиенту и возвращает false.
+func (a *AgentAuth) Verify(c *gin.Context, hostID uuid.UUID) bool {
+	// Клиентский сертификат агента должен принадлежать хосту из запроса
+	certHostID, hasCert, ok := pki.PeerHostID(c.Request.TLS)
+	if hasCert && (!ok || certHostID != hostID) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Client certificate does not match host",
+		})
+		return false
+	}
+
+	// Сертификат хоста сам по себе подтверждает агента, ключ тогда не обязателен
+	if key := c.GetHeader(models.AgentKeyHeader); key != "" {
+		ok, err := a.authenticate(c.Request.Context(), hostID, auth.HashSecret(key))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to verify agent key",
+			})
+			return false
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid agent key for this host",
+			})
+			return false
+		}
+	} else if !hasCert && !a.allowAnonymous {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Agent key or client certificate is required",
+		})
+		return false
+	}
+
+	return true
+}
+
+// authenticate проверяет, что ключ с хешем secretHash - действующий ключ хоста
+func (a *AgentAuth) authenticate(ctx context.Context, hostID uuid.UUID, secretHash string) (bool, error) {
+	now := time.Now()
+
+	a.mu.Lock()
+	key, ok := a.keys[secretHash]
+	a.mu.Unlock()
+
+	if !ok || now.After(key.expiresAt) {
+		owner, err := a.credentialRepo.FindHostBySecret(ctx, secretHash)
+		if err != nil {
+			return false, err
+		}
+		key = cachedKey{hostID: owner, expiresAt: now.Add(a.cacheTTL)}
+
+		a.mu.Lock()
+		a.keys[secretHash] = key
+		a.mu.Unlock()
+	}
+
+	if key.hostID == nil || *key.hostID != hostID {
+		return false, nil
+	}
+
+	a.mu.Lock()
+	a.used[secretHash] = now
+	a.mu.Unlock()
+
+	return true, nil
+}
+
+func (a *AgentAuth) flush(ctx context.Context) {
+	a.mu.Lock()
+	used := a.used
+	a.used = make(map[string]time.Time)
+	a.mu.Unlock()
+
+	if len(used) == 0 {
+		return
+	}
+
+	hashes := make([]string, 0, len(used))
+	times := make([]time.Time, 0, len(used))
+	for hash, usedAt := range used {
+		hashes = append(hashes, hash)
+		times = append(times, usedAt)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, keyUsageWriteTimeout)
+	defer cancel()
+
+	if err := a.credentialRepo.TouchLastUsed(ctx, hashes, times); err != nil {
+		log.Printf("Failed to record agent key usage: %v", err)
+
+		// Не записанное время вернется в следующую запись, если ключ не использовали снова
+		a.mu.Lock()
+		for hash, usedAt := range used {
+			if _, ok := a.used[hash]; !ok {
+				a.used[hash] = usedAt
+			}
+		}
+		a.mu.Unlock()
+	}
+}
+
+func (a *AgentAuth) prune(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for hash, key := range a.keys {
+		if now.After(key.expiresAt) {
+			delete(a.keys, hash)
+		}
+	}
+}
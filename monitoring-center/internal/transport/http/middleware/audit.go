@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+// Ключ контекста gin, под которым обработчик оставляет изменение объекта
+const auditChangeKey = "audit_change"
+
+// Таймаут записи в журнал: запись не должна задерживать ответ надолго
+const auditWriteTimeout = 5 * time.Second
+
+// Поля, которые меняются при любом изменении и не несут информации
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+type auditChange struct {
+	targetType string
+	targetID   string
+	before     json.RawMessage
+	after      json.RawMessage
+}
+
+// Auditor пишет в журнал аудита каждый изменяющий запрос к API: кто,
+// что и над каким объектом сделал, с разницей состояния до и после
+type Auditor struct {
+	auditRepo *postgres.AuditRepository
+	skip      map[string]bool
+}
+
+// NewAuditor создает аудитор. Маршруты skipPaths (например, прием метрик)
+// в журнал не попадают.
+func NewAuditor(auditRepo *postgres.AuditRepository, skipPaths ...string) *Auditor {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+	return &Auditor{auditRepo: auditRepo, skip: skip}
+}
+
+// Record записывает запрос в журнал после выполнения обработчика.
+// Ошибка записи только логируется и не меняет ответ клиенту.
+func (a *Auditor) Record() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutating(c.Request.Method) || c.FullPath() == "" || a.skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		entry := a.entry(c)
+
+		// Клиент мог уже закрыть соединение, запись все равно нужна
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), auditWriteTimeout)
+		defer cancel()
+
+		if err := a.auditRepo.Create(ctx, entry); err != nil {
+			log.Printf("Failed to write audit entry for %s %s (request %s): %v", entry.Method, entry.Path, entry.RequestID, err)
+		}
+	}
+}
+
+// AuditChange сообщает аудиту объект, измененный запросом, и его состояние
+// до и после. nil в before - объект создан, nil в after - удален.
+// Состояние сериализуется сразу, поэтому объект можно менять после вызова.
+func AuditChange(c *gin.Context, targetType, targetID string, before, after any) {
+	c.Set(auditChangeKey, &auditChange{
+		targetType: targetType,
+		targetID:   targetID,
+		before:     marshalState(before),
+		after:      marshalState(after),
+	})
+}
+
+func (a *Auditor) entry(c *gin.Context) *models.AuditEntry {
+	entry := &models.AuditEntry{
+		ActorName:  models.AnonymousActor,
+		Action:     handlerAction(c.HandlerName()),
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		TargetType: routeTarget(c.FullPath()),
+		TargetID:   c.Param("id"),
+		Status:     c.Writer.Status(),
+		RequestID:  GetRequestID(c),
+		SourceIP:   c.ClientIP(),
+	}
+
+	if user := CurrentUser(c); user != nil {
+		entry.ActorID = &user.ID
+		entry.ActorName = user.Username
+	}
+
+	if change := reportedChange(c); change != nil {
+		entry.TargetType = change.targetType
+		entry.TargetID = change.targetID
+		entry.Changes = diffStates(change.before, change.after)
+	}
+
+	return entry
+}
+
+// AuditChanges возвращает поля, изменение которых попадет в журнал по AuditChange
+func AuditChanges(c *gin.Context) map[string]models.FieldChange {
+	if change := reportedChange(c); change != nil {
+		return diffStates(change.before, change.after)
+	}
+	return nil
+}
+
+func reportedChange(c *gin.Context) *auditChange {
+	if value, ok := c.Get(auditChangeKey); ok {
+		if change, ok := value.(*auditChange); ok {
+			return change
+		}
+	}
+	return nil
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// handlerAction достает имя метода обработчика:
+// ".../handlers.(*HostHandler).UpdateHost-fm" -> "UpdateHost"
+func handlerAction(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// routeTarget возвращает тип объекта по маршруту: "/api/hosts/:id" -> "hosts"
+func routeTarget(route string) string {
+	route = strings.TrimPrefix(route, "/api/")
+	target, _, _ := strings.Cut(route, "/")
+	return target
+}
+
+func marshalState(state any) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("Failed to serialize audit state: %v", err)
+		return nil
+	}
+	return data
+}
+
+// diffStates сравнивает состояния по полям верхнего уровня и возвращает только
+// изменившиеся. Состояние, которое не является объектом, сравнивается целиком
+// как поле "value".
+func diffStates(before, after json.RawMessage) map[string]models.FieldChange {
+	beforeFields := stateFields(before)
+	afterFields := stateFields(after)
+
+	changes := make(map[string]models.FieldChange)
+	for field, value := range beforeFields {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		if other, ok := afterFields[field]; !ok || !bytes.Equal(value, other) {
+			changes[field] = models.FieldChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok && !auditIgnoredFields[field] {
+			changes[field] = models.FieldChange{After: value}
+		}
+	}
+
+	return changes
+}
+
+func stateFields(state json.RawMessage) map[string]json.RawMessage {
+	if len(state) == 0 || bytes.Equal(state, []byte("null")) {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(state, &fields); err != nil {
+		return map[string]json.RawMessage{"value": state}
+	}
+	return fields
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+)
+
+type change struct {
+	before string // "" - поля не было
+	after  string
+}
+
+func TestDiffStates(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string // "" - состояния нет (объект создан)
+		after  string // "" - состояния нет (объект удален)
+		want   map[string]change
+	}{
+		{
+			name:   "no changes",
+			before: `{"name":"web-1","priority":1}`,
+			after:  `{"name":"web-1","priority":1}`,
+			want:   map[string]change{},
+		},
+		{
+			name:   "changed scalar",
+			before: `{"name":"web-1","priority":1}`,
+			after:  `{"name":"web-1","priority":2}`,
+			want:   map[string]change{"priority": {`1`, `2`}},
+		},
+		{
+			name:   "added key",
+			before: `{"name":"web-1"}`,
+			after:  `{"name":"web-1","group_id":"g1"}`,
+			want:   map[string]change{"group_id": {"", `"g1"`}},
+		},
+		{
+			name:   "removed key",
+			before: `{"name":"web-1","group_id":"g1"}`,
+			after:  `{"name":"web-1"}`,
+			want:   map[string]change{"group_id": {`"g1"`, ""}},
+		},
+		{
+			name:   "key set to null",
+			before: `{"group_id":"g1"}`,
+			after:  `{"group_id":null}`,
+			want:   map[string]change{"group_id": {`"g1"`, `null`}},
+		},
+		{
+			name:   "nested object changed",
+			before: `{"name":"cpu","labels":{"env":"prod","tier":"web"}}`,
+			after:  `{"name":"cpu","labels":{"env":"stage","tier":"web"}}`,
+			want: map[string]change{
+				"labels": {`{"env":"prod","tier":"web"}`, `{"env":"stage","tier":"web"}`},
+			},
+		},
+		{
+			name:   "nested object unchanged",
+			before: `{"name":"cpu","labels":{"env":"prod"},"threshold":80}`,
+			after:  `{"name":"cpu","labels":{"env":"prod"},"threshold":90}`,
+			want:   map[string]change{"threshold": {`80`, `90`}},
+		},
+		{
+			name:   "nested array changed",
+			before: `{"processes":["nginx"]}`,
+			after:  `{"processes":["nginx","redis"]}`,
+			want:   map[string]change{"processes": {`["nginx"]`, `["nginx","redis"]`}},
+		},
+		{
+			name:   "updated_at ignored",
+			before: `{"name":"web-1","updated_at":"2024-01-01T00:00:00Z"}`,
+			after:  `{"name":"web-1","updated_at":"2024-01-02T00:00:00Z"}`,
+			want:   map[string]change{},
+		},
+		{
+			name:   "created",
+			before: "",
+			after:  `{"name":"web-1","updated_at":"2024-01-01T00:00:00Z"}`,
+			want:   map[string]change{"name": {"", `"web-1"`}},
+		},
+		{
+			name:   "deleted",
+			before: `{"name":"web-1","priority":1}`,
+			after:  "",
+			want:   map[string]change{"name": {`"web-1"`, ""}, "priority": {`1`, ""}},
+		},
+		{
+			name:   "null state",
+			before: `null`,
+			after:  `{"member":"h1"}`,
+			want:   map[string]change{"member": {"", `"h1"`}},
+		},
+		{
+			name:   "non-object state",
+			before: `"pending"`,
+			after:  `"acknowledged"`,
+			want:   map[string]change{"value": {`"pending"`, `"acknowledged"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffStates(raw(tt.before), raw(tt.after))
+			assertChanges(t, got, tt.want)
+		})
+	}
+}
+
+func TestMarshalStateSnapshot(t *testing.T) {
+	host := &models.Host{ID: uuid.New(), Name: "web-1"}
+
+	state := marshalState(host)
+	host.Name = "web-2"
+
+	// Состояние сериализуется сразу, поэтому изменения после вызова не видны
+	var fields map[string]any
+	if err := json.Unmarshal(state, &fields); err != nil {
+		t.Fatalf("failed to unmarshal state: %v", err)
+	}
+	if fields["name"] != "web-1" {
+		t.Errorf("name %v, want web-1", fields["name"])
+	}
+
+	if marshalState(nil) != nil {
+		t.Error("nil state must stay nil")
+	}
+}
+
+func raw(state string) json.RawMessage {
+	if state == "" {
+		return nil
+	}
+	return json.RawMessage(state)
+}
+
+func assertChanges(t *testing.T, got map[string]models.FieldChange, want map[string]change) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Errorf("got %d changed fields %v, want %d", len(got), fieldNames(got), len(want))
+	}
+	for field, w := range want {
+		g, ok := got[field]
+		if !ok {
+			t.Errorf("field %q not reported as changed", field)
+			continue
+		}
+		if !bytes.Equal(g.Before, raw(w.before)) {
+			t.Errorf("field %q before = %s, want %s", field, g.Before, w.before)
+		}
+		if !bytes.Equal(g.After, raw(w.after)) {
+			t.Errorf("field %q after = %s, want %s", field, g.After, w.after)
+		}
+	}
+}
+
+func fieldNames(changes map[string]models.FieldChange) []string {
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nekitmilk/monitoring-center/internal/auth"
+	"github.com/nekitmilk/monitoring-center/internal/models"
+	"github.com/nekitmilk/monitoring-center/internal/storage/postgres"
+)
+
+// Ключ контекста gin, под которым хранится аутентифицированный пользователь
+const userKey = "user"
+
+// Authenticator проверяет пользователя API по токену (Authorization: Bearer)
+// или по имени и паролю (Authorization: Basic) и его роль
+type Authenticator struct {
+	userRepo *postgres.UserRepository
+	// Вход по паролю через Basic ограничивается так же, как /api/auth/login
+	limiter *LoginLimiter
+}
+
+func NewAuthenticator(userRepo *postgres.UserRepository, limiter *LoginLimiter) *Authenticator {
+	return &Authenticator{userRepo: userRepo, limiter: limiter}
+}
+
+// RequireRole пропускает запрос пользователя с ролью не ниже role
+func (a *Authenticator) RequireRole(role models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := a.authenticate(c)
+		if !ok {
+			return
+		}
+
+		if !user.Role.Allows(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Insufficient permissions",
+				"details": fmt.Sprintf("%s role is required", role),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CurrentUser возвращает пользователя, аутентифицированного RequireRole
+func CurrentUser(c *gin.Context) *models.User {
+	if value, ok := c.Get(userKey); ok {
+		if user, ok := value.(*models.User); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// authenticate определяет пользователя запроса. При отказе отвечает клиенту и возвращает false.
+func (a *Authenticator) authenticate(c *gin.Context) (*models.User, bool) {
+	if user := CurrentUser(c); user != nil {
+		return user, true
+	}
+
+	ctx := c.Request.Context()
+	header := c.GetHeader("Authorization")
+
+	var user *models.User
+	var err error
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		user, err = a.userRepo.Authenticate(ctx, auth.HashSecret(strings.TrimSpace(token)))
+	} else if username, password, ok := c.Request.BasicAuth(); ok {
+		if wait := a.limiter.Allow(c.ClientIP(), username); wait > 0 {
+			TooManyLogins(c, wait)
+			return nil, false
+		}
+		user, err = a.userRepo.FindByUsername(ctx, username)
+		if err == nil {
+			if auth.VerifyUser(user, password) {
+				a.limiter.Succeeded(username)
+			} else {
+				a.limiter.Failed(c.ClientIP(), username)
+				user = nil
+			}
+		}
+	}
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to authenticate",
+		})
+		return nil, false
+	}
+	if user == nil {
+		c.Header("WWW-Authenticate", `Bearer realm="monitoring-center"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return nil, false
+	}
+
+	c.Set(userKey, user)
+	return user, true
+}
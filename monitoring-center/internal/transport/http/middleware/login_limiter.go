@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginLimiter ограничивает подбор паролей: после maxPerUser неудачных входов
+// под одним именем или maxPerIP с одного адреса вход блокируется до конца окна
+// window, отсчитываемого от первой неудачи. Успешный вход сбрасывает счетчик
+// имени. Счетчики хранятся в памяти и не разделяются между экземплярами центра.
+type LoginLimiter struct {
+	maxPerUser int
+	maxPerIP   int
+	window     time.Duration
+
+	mu        sync.Mutex
+	failures  map[string]*loginFailures // "user:<имя>" или "ip:<адрес>"
+	lastPrune time.Time
+}
+
+type loginFailures struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewLoginLimiter(maxPerUser, maxPerIP int, window time.Duration) *LoginLimiter {
+	if maxPerUser <= 0 {
+		maxPerUser = 5
+	}
+	if maxPerIP <= 0 {
+		maxPerIP = 20
+	}
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+
+	return &LoginLimiter{
+		maxPerUser: maxPerUser,
+		maxPerIP:   maxPerIP,
+		window:     window,
+		failures:   make(map[string]*loginFailures),
+		lastPrune:  time.Now(),
+	}
+}
+
+// Allow возвращает 0, если вход разрешен, иначе - сколько ждать до следующей попытки
+func (l *LoginLimiter) Allow(ip, username string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	if entry := l.active(userKeyFor(username), now); entry != nil && entry.count >= l.maxPerUser {
+		wait = entry.resetAt.Sub(now)
+	}
+	if entry := l.active(ipKeyFor(ip), now); entry != nil && entry.count >= l.maxPerIP {
+		wait = max(wait, entry.resetAt.Sub(now))
+	}
+	return wait
+}
+
+// Failed учитывает неудачный вход
+func (l *LoginLimiter) Failed(ip, username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+	for _, key := range []string{userKeyFor(username), ipKeyFor(ip)} {
+		entry := l.active(key, now)
+		if entry == nil {
+			entry = &loginFailures{resetAt: now.Add(l.window)}
+			l.failures[key] = entry
+		}
+		entry.count++
+	}
+}
+
+// Succeeded сбрасывает неудачи имени после успешного входа.
+// Счетчик адреса не сбрасывается, чтобы свой аккаунт не открывал подбор чужих.
+func (l *LoginLimiter) Succeeded(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, userKeyFor(username))
+}
+
+// active возвращает счетчик key, если его окно еще не истекло
+func (l *LoginLimiter) active(key string, now time.Time) *loginFailures {
+	entry, ok := l.failures[key]
+	if !ok {
+		return nil
+	}
+	if !now.Before(entry.resetAt) {
+		delete(l.failures, key)
+		return nil
+	}
+	return entry
+}
+
+// prune раз в окно удаляет истекшие счетчики, чтобы карта не росла от перебора имен
+func (l *LoginLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	l.lastPrune = now
+	for key, entry := range l.failures {
+		if !now.Before(entry.resetAt) {
+			delete(l.failures, key)
+		}
+	}
+}
+
+// TooManyLogins отвечает 429 с Retry-After на заблокированную попытку входа
+func TooManyLogins(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": "Too many failed login attempts",
+	})
+}
+
+func userKeyFor(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKeyFor(ip string) string {
+	return "ip:" + ip
+}
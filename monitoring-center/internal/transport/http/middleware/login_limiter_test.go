@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLimiterPerUser(t *testing.T) {
+	l := NewLoginLimiter(3, 100, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if wait := l.Allow("10.0.0.1", "alice"); wait != 0 {
+			t.Fatalf("attempt %d blocked for %v", i, wait)
+		}
+		l.Failed("10.0.0.1", "alice")
+	}
+
+	// Имя блокируется с любого адреса и без учета регистра
+	if wait := l.Allow("10.0.0.2", "Alice"); wait <= 0 || wait > time.Minute {
+		t.Errorf("Allow after 3 failures = %v, want wait within window", wait)
+	}
+	if wait := l.Allow("10.0.0.1", "bob"); wait != 0 {
+		t.Errorf("other user blocked for %v", wait)
+	}
+}
+
+func TestLoginLimiterPerIP(t *testing.T) {
+	l := NewLoginLimiter(100, 3, time.Minute)
+
+	// Перебор разных имен с одного адреса
+	for _, username := range []string{"a", "b", "c"} {
+		l.Failed("10.0.0.1", username)
+	}
+
+	if wait := l.Allow("10.0.0.1", "d"); wait <= 0 {
+		t.Error("address not blocked after 3 failures")
+	}
+	if wait := l.Allow("10.0.0.2", "d"); wait != 0 {
+		t.Errorf("other address blocked for %v", wait)
+	}
+}
+
+func TestLoginLimiterSucceededResetsUser(t *testing.T) {
+	l := NewLoginLimiter(2, 3, time.Minute)
+
+	l.Failed("10.0.0.1", "alice")
+	l.Failed("10.0.0.1", "alice")
+	l.Succeeded("alice")
+
+	if wait := l.Allow("10.0.0.2", "alice"); wait != 0 {
+		t.Errorf("user blocked for %v after successful login", wait)
+	}
+	// Счетчик адреса успешным входом не сбрасывается
+	l.Failed("10.0.0.1", "bob")
+	if wait := l.Allow("10.0.0.1", "carol"); wait <= 0 {
+		t.Error("address counter was reset by successful login")
+	}
+}
+
+func TestLoginLimiterWindowExpires(t *testing.T) {
+	l := NewLoginLimiter(1, 1, 20*time.Millisecond)
+
+	l.Failed("10.0.0.1", "alice")
+	if wait := l.Allow("10.0.0.1", "alice"); wait <= 0 {
+		t.Fatal("not blocked after failure")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if wait := l.Allow("10.0.0.1", "alice"); wait != 0 {
+		t.Errorf("still blocked for %v after window", wait)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// Ключ контекста gin, под которым хранится идентификатор запроса
+const requestIDKey = "request_id"
+
+const maxRequestIDLength = 64
+
+// RequestID берет идентификатор запроса из X-Request-ID или создает новый
+// и возвращает его клиенту в том же заголовке
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID возвращает идентификатор текущего запроса
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// validRequestID допускает только короткие идентификаторы из печатных символов,
+// чтобы клиент не мог записать в журнал произвольный текст
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,8 @@
 #!/bin/bash
 
 # Возможно имеет смысл добавить хост в миграцию и убрать этот скрипт
+# Не нужен, если агент регистрируется сам: задайте BOOTSTRAP_TOKENS в ЦМ,
+# ENROLL_TOKEN агенту и оставьте HOST_ID пустым
 # Load environment variables
 set -a
 # source ../deployments/.env
@@ -13,19 +15,56 @@ until curl -s http://localhost:8080/api/hosts >/dev/null; do
     sleep 2
 done
 
+ENV_FILE=deployments/.env
+
+# API ЦМ доступен только пользователям: нужен администратор из ADMIN_PASSWORD
+if [ -z "$ADMIN_PASSWORD" ]; then
+    echo "ADMIN_PASSWORD is not set in $ENV_FILE"
+    exit 1
+fi
+AUTH="${ADMIN_USERNAME:-admin}:$ADMIN_PASSWORD"
+
+# Записывает KEY=VALUE в .env, заменяя прежнее значение
+set_env() {
+    if grep -q "^$1=" "$ENV_FILE"; then
+        sed -i "s|^$1=.*|$1=$2|" "$ENV_FILE"
+    else
+        echo "$1=$2" >> "$ENV_FILE"
+    fi
+}
+
+# Секрет ключа агента из ответа ЦМ
+extract_secret() {
+    echo "$1" | grep -o '"secret":"[0-9a-f]*"' | cut -d'"' -f4
+}
+
 # Check if host already exists
 echo "Checking if host $HOST_ID already exists..."
-response=$(curl -s -o /dev/null -w "%{http_code}" \
+response=$(curl -s -o /dev/null -w "%{http_code}" -u "$AUTH" \
   "http://localhost:8080/api/hosts/$HOST_ID")
 
 if [ "$response" -eq 200 ]; then
-    echo "Host $HOST_ID already exists. Skipping registration."
+    if [ -n "$AGENT_KEY" ]; then
+        echo "Host $HOST_ID already exists. Skipping registration."
+        exit 0
+    fi
+
+    # Секрет показывается только при выдаче, поэтому без него выпускаем новый ключ
+    echo "Host $HOST_ID already exists but AGENT_KEY is not set. Rotating agent key..."
+    response=$(curl -s -u "$AUTH" -X POST "http://localhost:8080/api/hosts/$HOST_ID/credentials/rotate")
+    secret=$(extract_secret "$response")
+    if [ -z "$secret" ]; then
+        echo "Failed to rotate agent key: $response"
+        exit 1
+    fi
+    set_env AGENT_KEY "$secret"
+    echo "Agent key saved to $ENV_FILE"
     exit 0
 fi
 
 # Register new host
 echo "Registering host $HOST_NAME with ID $HOST_ID..."
-response=$(curl -s -w "%{http_code}" -X POST http://localhost:8080/api/hosts \
+response=$(curl -s -w "%{http_code}" -u "$AUTH" -X POST http://localhost:8080/api/hosts \
   -H "Content-Type: application/json" \
   -d "{
     \"name\": \"$HOST_NAME\",
@@ -37,7 +76,12 @@ status_code=${response: -3}
 response_body=${response%???}
 
 if [ "$status_code" -eq 201 ]; then
-    echo "Host registered successfully: $response_body"
+    echo "Host registered successfully"
+    # ЦМ сам назначает ID хоста, агенту нужны именно он и выданный ключ
+    host_id=$(echo "$response_body" | grep -o '"id":"[^"]*"' | head -n 1 | cut -d'"' -f4)
+    set_env HOST_ID "$host_id"
+    set_env AGENT_KEY "$(extract_secret "$response_body")"
+    echo "HOST_ID=$host_id and AGENT_KEY saved to $ENV_FILE"
 elif [ "$status_code" -eq 400 ]; then
     echo "Host already exists or invalid data: $response_body"
 else
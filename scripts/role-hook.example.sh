@@ -0,0 +1,24 @@
+#!/bin/sh
+# Пример скрипта для ON_PROMOTE_SCRIPT / ON_DEMOTE_SCRIPT агента.
+# Агент передает в окружении:
+#   ROLE          - новая роль: master, backup или unknown
+#   PREVIOUS_ROLE - предыдущая роль
+#   MASTER_TERM   - term аренды мастера (fencing-токен)
+#   HOST_ID       - ID хоста
+#   GROUP         - имя группы хостов
+#
+# ON_PROMOTE_SCRIPT="/etc/monitoring-agent/role-hook.sh"
+# ON_DEMOTE_SCRIPT="/etc/monitoring-agent/role-hook.sh"
+
+set -eu
+
+echo "$(date -Iseconds) host=${HOST_ID} group=${GROUP} ${PREVIOUS_ROLE} -> ${ROLE} (term ${MASTER_TERM})"
+
+case "${ROLE}" in
+master)
+    # Например: поднять VIP или перевести реплику БД в primary
+    ;;
+*)
+    # Например: снять VIP
+    ;;
+esac